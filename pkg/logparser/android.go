@@ -0,0 +1,82 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Log formats supported in Options.LogFormat.
+const (
+	// LogFormatKernel is a plain kernel console log (e.g. a serial console output or dmesg).
+	LogFormatKernel = "kernel"
+	// LogFormatAndroid is a kernel log captured on Android, see preprocessAndroid.
+	LogFormatAndroid = "android"
+)
+
+// LogFormats lists all supported log formats.
+var LogFormats = []string{LogFormatKernel, LogFormatAndroid}
+
+var (
+	// androidLogcatRe matches the prefix of "adb logcat -b kernel" lines:
+	// "01-02 03:04:05.678     0     0 I         : " or "01-02 03:04:05.678  0  0 W kworker/0:1: ".
+	androidLogcatRe = regexp.MustCompile(`^[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]+ +[0-9]+ +[0-9]+ ` +
+		`[VDIWEFS] +[^ ]*?: `)
+	// androidKmsgRe matches the prefix of /dev/kmsg records: "6,1234,12345678,-;".
+	androidKmsgRe = regexp.MustCompile(`^[0-9]+,[0-9]+,([0-9]+),[^;]*;`)
+	// androidPriorityRe matches the syslog priority of last_kmsg and "dmesg -r" lines: "<6>".
+	androidPriorityRe = regexp.MustCompile(`^<[0-9]+>`)
+)
+
+// preprocessAndroid converts kernel logs captured on Android to the plain console format
+// by rewriting the line prefixes added by the capturing tools:
+//   - logcat prefixes (date, time, pid, tid, level and tag) are removed;
+//   - /dev/kmsg record prefixes are replaced with the "[   12.345678] " console timestamps;
+//   - syslog priorities ("<6>") are removed.
+//
+// Other lines are left as is. The result is always a copy of the whole log.
+func preprocessAndroid(data []byte) ([]byte, editMap) {
+	res := make([]byte, 0, len(data))
+	var edits editMap
+	for pos := 0; pos < len(data); {
+		end := bytes.IndexByte(data[pos:], '\n') + 1
+		if end == 0 {
+			end = len(data) - pos
+		}
+		line := data[pos : pos+end]
+		prefixLen, replacement := androidPrefix(line)
+		if prefixLen != 0 {
+			edits = append(edits, edit{
+				conv:    len(res),
+				orig:    pos,
+				convLen: len(replacement),
+				origLen: prefixLen,
+			})
+		}
+		res = append(res, replacement...)
+		res = append(res, line[prefixLen:]...)
+		pos += end
+	}
+	return res, edits
+}
+
+// androidPrefix returns the length of the Android prefix of line and what it's replaced with.
+func androidPrefix(line []byte) (int, []byte) {
+	if match := androidLogcatRe.Find(line); match != nil {
+		return len(match), nil
+	}
+	if match := androidKmsgRe.FindSubmatch(line); match != nil {
+		usec, err := strconv.ParseUint(string(match[1]), 10, 64)
+		if err == nil {
+			return len(match[0]), []byte(fmt.Sprintf("[%5d.%06d] ", usec/1e6, usec%1e6))
+		}
+	}
+	if match := androidPriorityRe.Find(line); match != nil {
+		return len(match), nil
+	}
+	return 0, nil
+}
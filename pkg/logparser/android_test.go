@@ -0,0 +1,69 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreprocessAndroid(t *testing.T) {
+	const log = "01-02 03:04:05.678     0     0 I         : [   10.000000] first\n" +
+		"6,1234,12345678,-;second\n" +
+		"<6>[   12.000000] third\n" +
+		"unchanged\n" +
+		"01-02 03:04:05.678  0  0 W kworker/0:1: last"
+	data, edits := preprocessAndroid([]byte(log))
+	assert.Equal(t, "[   10.000000] first\n"+
+		"[   12.345678] second\n"+
+		"[   12.000000] third\n"+
+		"unchanged\n"+
+		"last", string(data))
+	for _, word := range []string{"first", "second", "third", "unchanged", "last"} {
+		conv, orig := strings.Index(string(data), word), strings.Index(log, word)
+		assert.Equal(t, conv, edits.convertedPos(orig), word)
+		assert.Equal(t, orig+1, edits.origPos(conv+1), word)
+		// Line starts map to line starts (including the ones of removed prefixes).
+		convStart := strings.LastIndexByte(string(data[:conv]), '\n') + 1
+		origStart := strings.LastIndexByte(log[:orig], '\n') + 1
+		assert.Equal(t, origStart, edits.origPos(convStart), word)
+	}
+	// The message right after a replaced prefix.
+	assert.Equal(t, strings.Index(log, "second"), edits.origPos(strings.Index(string(data), "second")))
+	assert.Equal(t, len(log), edits.origPos(len(data)))
+
+	data, edits = preprocessAndroid([]byte("[   10.000000] plain\n"))
+	assert.Equal(t, "[   10.000000] plain\n", string(data))
+	assert.Empty(t, edits)
+}
+
+func TestParseAndroid(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	logcat := regexp.MustCompile(`(?m)^`).ReplaceAllString(log, "01-02 03:04:05.678     0     0 I         : ")
+	logcat = strings.TrimSuffix(logcat, "01-02 03:04:05.678     0     0 I         : ")
+	opts := &Options{OS: targets.Linux, Arch: targets.AMD64, RawRange: true}
+	want, err := Parse(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.Len(t, want, 1)
+	opts.LogFormat = LogFormatAndroid
+	got, err := Parse(strings.NewReader(logcat), opts)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, want[0].Title, got[0].Title)
+	assert.Equal(t, want[0].Report, got[0].Report)
+	assert.Equal(t, want[0].RawRange, got[0].RawRange)
+	assert.True(t, strings.HasPrefix(logcat[got[0].StartPos:],
+		"01-02 03:04:05.678     0     0 I         : [   10.000000] WARNING:"), logcat[got[0].StartPos:])
+
+	_, err = Parse(strings.NewReader(log), &Options{LogFormat: "ios"})
+	assert.ErrorContains(t, err, `unknown -log-format "ios" (supported: kernel, android)`)
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedaction replaces matches of anonymization patterns that don't specify a placeholder.
+const DefaultRedaction = "<REDACTED>"
+
+// redaction replaces the matches of re with placeholder (which may refer to submatches as $1).
+type redaction struct {
+	re          *regexp.Regexp
+	placeholder string
+}
+
+// defaultRedactions are applied with Options.Anonymize in order, before the patterns from
+// Options.AnonymizePatterns. MAC addresses go before IPv6 addresses they look like, and
+// user@host pairs before host names.
+var defaultRedactions = []redaction{
+	{regexp.MustCompile(`\b[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}\b|\b[0-9a-fA-F]{2}(-[0-9a-fA-F]{2}){5}\b`), "<MAC>"},
+	// Only the full and the "::" forms, because short colon-separated hex lists are common
+	// in kernel logs (e.g. SCSI addresses like "0:0:0:0").
+	{regexp.MustCompile(`\b([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b|` +
+		`\b([0-9a-fA-F]{1,4}:){1,6}(:[0-9a-fA-F]{1,4}){1,6}\b`), "<IP>"},
+	{regexp.MustCompile(`\b([0-9]{1,3}\.){3}[0-9]{1,3}\b`), "<IP>"},
+	{regexp.MustCompile(`\b[a-zA-Z0-9._+-]+@[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*`), "<USER>@<HOST>"},
+	{regexp.MustCompile(`\b([a-zA-Z0-9-]+\.)+(com|net|org|io|dev|cloud|local|localdomain|internal|lan|corp|home)\b`),
+		"<HOST>"},
+}
+
+// loadRedactions reads additional anonymization patterns from the file, one per line,
+// in the "regexp" or "regexp => placeholder" form (DefaultRedaction is used if the
+// placeholder is omitted). Empty lines and lines starting with # are ignored.
+func loadRedactions(file string) ([]redaction, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anonymize patterns: %w", err)
+	}
+	var res []redaction
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pattern, placeholder, ok := strings.Cut(text, " => ")
+		if !ok {
+			placeholder = DefaultRedaction
+		}
+		re, err := regexp.Compile(strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("%v:%v: bad anonymize regexp: %w", file, line, err)
+		}
+		res = append(res, redaction{re, strings.TrimSpace(placeholder)})
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read anonymize patterns: %w", err)
+	}
+	return res, nil
+}
+
+// redact applies all redactions to text in order.
+func redact(text string, redactions []redaction) string {
+	for _, r := range redactions {
+		text = r.re.ReplaceAllString(text, r.placeholder)
+	}
+	return text
+}
+
+// Anonymize redacts host-specific data (IP and MAC addresses, host and user names) in data
+// the same way as in crashes with Options.Anonymize. It returns data as is otherwise.
+func (p *Parser) Anonymize(data []byte) []byte {
+	if p.redactions == nil {
+		return data
+	}
+	for _, r := range p.redactions {
+		data = r.re.ReplaceAll(data, []byte(r.placeholder))
+	}
+	return data
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"connect to 10.128.0.42:22 failed", "connect to <IP>:22 failed"},
+		{"eth0: link 52:54:00:12:34:56 up", "eth0: link <MAC> up"},
+		{"hwaddr 52-54-00-12-34-56", "hwaddr <MAC>"},
+		{"fe80::5054:ff:fe12:3456 and 2001:db8::1", "<IP> and <IP>"},
+		{"addr 2001:0db8:85a3:0000:0000:8a2e:0370:7334", "addr <IP>"},
+		{"Linux version 6.1.0 (root@build-42.corp.example.com) #1", "Linux version 6.1.0 (<USER>@<HOST>) #1"},
+		{"ssh from runner-7.c.project.internal", "ssh from <HOST>"},
+		// Things that must stay intact.
+		{"[   10.000000] sd 0:0:0:0: [sda] 4096-byte physical blocks", ""},
+		{"RIP: 0010:foo+0x1/0x2 fs/ext4/inode.c:1234", ""},
+		{"pci 0000:00:01.0: [8086:7000] type 00 class 0x060100", ""},
+		{"kernel::str::CStr::as_bytes", ""},
+	}
+	for _, test := range tests {
+		want := test.want
+		if want == "" {
+			want = test.text
+		}
+		assert.Equal(t, want, redact(test.text, defaultRedactions), test.text)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] peer 192.168.1.7 token=s3cr3t\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n" +
+		"[   11.000000] dhcp lease from 192.168.1.1\n"
+	patterns := filepath.Join(t.TempDir(), "patterns")
+	assert.NoError(t, os.WriteFile(patterns, []byte("# secrets\n\ntoken=\\S+ => token=<TOKEN>\nlease\n"), 0644))
+	parser, err := NewParser(&Options{
+		OS:                targets.Linux,
+		Arch:              targets.AMD64,
+		Context:           1,
+		AnonymizePatterns: patterns,
+	})
+	assert.NoError(t, err)
+	parsed, err := parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	crash := parsed.Crashes[0]
+	assert.Contains(t, crash.Report, "\npeer <IP> token=<TOKEN>\n")
+	assert.Contains(t, crash.Report, "\ndhcp <REDACTED> from <IP>\n")
+	assert.Equal(t, "[   10.000000] peer <IP> token=<TOKEN>\n", crash.ContextAfter)
+	assert.Equal(t, strings.Count(log, "\n"), strings.Count(string(parser.Anonymize([]byte(log))), "\n"))
+	assert.NotContains(t, string(parser.Anonymize([]byte(log))), "192.168")
+
+	parser, err = NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64})
+	assert.NoError(t, err)
+	assert.Equal(t, log, string(parser.Anonymize([]byte(log))))
+
+	assert.NoError(t, os.WriteFile(patterns, []byte("ok\n(\n"), 0644))
+	_, err = NewParser(&Options{AnonymizePatterns: patterns})
+	assert.ErrorContains(t, err, patterns+":2: bad anonymize regexp")
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTimestampRegexp matches Linux console timestamps (with optional caller ids)
+// like "[  123.456789]" or "[  123.456789][ T1234]".
+const DefaultTimestampRegexp = `\[ *[0-9]+\.[0-9]+\](\[ *[CT][0-9]+\])? ?`
+
+// linesBefore returns up to n lines of data that precede the line containing pos.
+func linesBefore(data []byte, pos, n int) string {
+	pos = min(max(pos, 0), len(data))
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	begin := start
+	for i := 0; i < n && begin > 0; i++ {
+		begin = bytes.LastIndexByte(data[:begin-1], '\n') + 1
+	}
+	return string(data[begin:start])
+}
+
+// linesAfter returns up to n lines of data that follow the line ending at pos.
+func linesAfter(data []byte, pos, n int) string {
+	pos = min(max(pos, 0), len(data))
+	start := pos
+	if pos == 0 || data[pos-1] != '\n' {
+		nl := bytes.IndexByte(data[pos:], '\n')
+		if nl == -1 {
+			return ""
+		}
+		start = pos + nl + 1
+	}
+	end := start
+	for i := 0; i < n && end < len(data); i++ {
+		nl := bytes.IndexByte(data[end:], '\n')
+		if nl == -1 {
+			end = len(data)
+			break
+		}
+		end += nl + 1
+	}
+	return string(data[start:end])
+}
+
+// rawRange returns data[start:end], or false if the range is empty or does not fit into data.
+func rawRange(data []byte, start, end int) (string, bool) {
+	if start < 0 || end <= start || end > len(data) {
+		return "", false
+	}
+	return string(data[start:end]), true
+}
+
+// stripTimestamps removes the prefixes matched by re from the beginning of every line of text.
+func stripTimestamps(text string, re *regexp.Regexp) string {
+	if text == "" {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if loc := re.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			lines[i] = line[loc[1]:]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// BodyTruncatedMarker is appended to report bodies truncated by Options.MaxBodyBytes.
+const BodyTruncatedMarker = "...[truncated]\n"
+
+// truncateBody cuts text to at most n bytes at the last line boundary
+// (or at a character boundary if the first line is longer than n) and appends
+// BodyTruncatedMarker. It returns whether text was truncated.
+func truncateBody(text string, n int) (string, bool) {
+	if len(text) <= n {
+		return text, false
+	}
+	cut := n
+	if nl := strings.LastIndexByte(text[:n], '\n'); nl != -1 {
+		cut = nl + 1
+	} else {
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return text[:cut] + BodyTruncatedMarker, true
+}
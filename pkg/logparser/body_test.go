@@ -0,0 +1,138 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextLines(t *testing.T) {
+	data := []byte("a\nb\nc\nBUG\nd\ne\nf")
+	start, end := 6, 9 // the "BUG" line
+	assert.Equal(t, "b\nc\n", linesBefore(data, start, 2))
+	assert.Equal(t, "a\nb\nc\n", linesBefore(data, start, 10))
+	assert.Equal(t, "", linesBefore(data, start, 0))
+	assert.Equal(t, "d\ne\n", linesAfter(data, end, 2))
+	assert.Equal(t, "d\ne\nf", linesAfter(data, end, 10))
+	assert.Equal(t, "", linesAfter(data, end, 0))
+	// Positions are clamped to the data.
+	assert.Equal(t, "", linesBefore(data, -5, 3))
+	assert.Equal(t, "", linesAfter(data, 100, 3))
+	assert.Equal(t, "", linesBefore(nil, 0, 3))
+	assert.Equal(t, "", linesAfter(nil, 0, 3))
+}
+
+func TestRawRange(t *testing.T) {
+	data := []byte("abcdef")
+	for _, test := range []struct {
+		start, end int
+		raw        string
+		ok         bool
+	}{
+		{1, 4, "bcd", true},
+		{0, 6, "abcdef", true},
+		{0, 0, "", false},
+		{4, 2, "", false},
+		{2, 7, "", false},
+		{-1, 2, "", false},
+	} {
+		raw, ok := rawRange(data, test.start, test.end)
+		assert.Equal(t, test.raw, raw, "[%v, %v]", test.start, test.end)
+		assert.Equal(t, test.ok, ok, "[%v, %v]", test.start, test.end)
+	}
+}
+
+func TestStripTimestamps(t *testing.T) {
+	re := regexp.MustCompile(DefaultTimestampRegexp)
+	assert.Equal(t, "BUG: foo\nCall Trace:\n bar+0x1/0x2\nno timestamp [ 1.0] here\n",
+		stripTimestamps("[   12.345678] BUG: foo\n[   12.345678][ T1234] Call Trace:\n"+
+			"[12.3]  bar+0x1/0x2\nno timestamp [ 1.0] here\n", re))
+	assert.Equal(t, "a\nb", stripTimestamps("[ 1.0] a\n[ 2.0] b", re))
+	assert.Equal(t, "", stripTimestamps("", re))
+	// Custom regexps are anchored at the line start.
+	re = regexp.MustCompile(`[0-9]{2}:[0-9]{2}:[0-9]{2} `)
+	assert.Equal(t, "panic\nat 10:00:00 x\n", stripTimestamps("10:00:00 panic\nat 10:00:00 x\n", re))
+}
+
+func TestTruncateBody(t *testing.T) {
+	tests := []struct {
+		text      string
+		n         int
+		want      string
+		truncated bool
+	}{
+		{"BUG: foo\nCall Trace:\n", 100, "BUG: foo\nCall Trace:\n", false},
+		{"BUG: foo\nCall Trace:\n", 21, "BUG: foo\nCall Trace:\n", false},
+		{"BUG: foo\nCall Trace:\n", 20, "BUG: foo\n" + BodyTruncatedMarker, true},
+		{"BUG: foo\nCall Trace:\n", 9, "BUG: foo\n" + BodyTruncatedMarker, true},
+		// No line boundary within the limit.
+		{"BUG: foo\nCall Trace:\n", 5, "BUG: " + BodyTruncatedMarker, true},
+		// Multi-byte characters are not split.
+		{"BUG: fö\n", 7, "BUG: f" + BodyTruncatedMarker, true},
+	}
+	for _, test := range tests {
+		got, truncated := truncateBody(test.text, test.n)
+		assert.Equal(t, test.want, got, "%q/%v", test.text, test.n)
+		assert.Equal(t, test.truncated, truncated, "%q/%v", test.text, test.n)
+	}
+}
+
+func TestReportBytes(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	parse := func(opts *Options, log string) *Report {
+		opts.OS, opts.Arch = targets.Linux, targets.AMD64
+		parser, err := NewParser(opts)
+		assert.NoError(t, err)
+		parsed, err := parser.ParseLog([]byte(log), "")
+		assert.NoError(t, err)
+		return parsed.Crashes[0]
+	}
+	full := parse(&Options{RawRange: true}, log)
+	assert.Equal(t, len(full.Report), full.ReportBytes)
+	assert.Equal(t, len(full.RawRange), full.RawRangeBytes)
+	assert.Equal(t, full.EndPos-full.StartPos, full.RawRangeBytes)
+	// The sizes are the ones of the log, not of the (possibly shortened) output.
+	for _, opts := range []*Options{{NoBody: true}, {MaxBodyBytes: 20}, {StripTimestamps: true}} {
+		crash := parse(opts, log)
+		assert.Equal(t, full.ReportBytes, crash.ReportBytes, "%+v", opts)
+		assert.Equal(t, full.RawRangeBytes, crash.RawRangeBytes, "%+v", opts)
+	}
+	// Positions refer to the log as is, CRs included.
+	crash := parse(&Options{}, strings.ReplaceAll(log, "\n", "\r\n"))
+	assert.Equal(t, full.ReportBytes, crash.ReportBytes)
+	assert.Equal(t, full.RawRangeBytes+strings.Count(full.RawRange, "\n"), crash.RawRangeBytes)
+}
+
+func TestNoBody(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	parse := func(opts *Options) *Report {
+		opts.OS, opts.Arch, opts.Context = targets.Linux, targets.AMD64, 1
+		parser, err := NewParser(opts)
+		assert.NoError(t, err)
+		parsed, err := parser.ParseLog([]byte(log), "")
+		assert.NoError(t, err)
+		return parsed.Crashes[0]
+	}
+	full := parse(&Options{})
+	crash := parse(&Options{NoBody: true})
+	assert.NotEmpty(t, full.Report)
+	assert.Empty(t, crash.Report)
+	// Only the body is omitted, the metadata is the same.
+	full.Report = ""
+	assert.Equal(t, full, crash)
+	assert.Equal(t, "[   10.000000] ------------[ cut here ]------------\n", crash.ContextBefore)
+}
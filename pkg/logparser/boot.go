@@ -0,0 +1,43 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// DefaultBootRegexp matches the Linux kernel boot banner.
+const DefaultBootRegexp = `Linux version [0-9]`
+
+// logSegment is a part of the log that starts at offset pos.
+type logSegment struct {
+	pos  int
+	data []byte
+}
+
+// splitBoots splits data into segments that start at the beginning of lines matching bootRe.
+// Output before the first boot banner (if any) forms a separate segment.
+func splitBoots(data []byte, bootRe *regexp.Regexp) []logSegment {
+	var starts []int
+	for _, match := range bootRe.FindAllIndex(data, -1) {
+		start := bytes.LastIndexByte(data[:match[0]], '\n') + 1
+		if len(starts) != 0 && starts[len(starts)-1] == start {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	if len(starts) == 0 || starts[0] != 0 {
+		starts = append([]int{0}, starts...)
+	}
+	var segments []logSegment
+	for i, start := range starts {
+		end := len(data)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		segments = append(segments, logSegment{start, data[start:end]})
+	}
+	return segments
+}
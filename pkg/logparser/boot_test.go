@@ -0,0 +1,30 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitBoots(t *testing.T) {
+	re := regexp.MustCompile(DefaultBootRegexp)
+	data := []byte(`early output
+[    0.000000] Linux version 6.1.0 (gcc) Linux version 6.1.0
+BUG: first boot
+[    0.000000] Linux version 6.2.0
+BUG: second boot
+`)
+	segments := splitBoots(data, re)
+	assert.Equal(t, []logSegment{
+		{0, []byte("early output\n")},
+		{13, []byte("[    0.000000] Linux version 6.1.0 (gcc) Linux version 6.1.0\nBUG: first boot\n")},
+		{90, []byte("[    0.000000] Linux version 6.2.0\nBUG: second boot\n")},
+	}, segments)
+
+	assert.Equal(t, []logSegment{{0, []byte("no banner\n")}}, splitBoots([]byte("no banner\n"), re))
+	assert.Equal(t, []logSegment{{0, []byte("Linux version 5.0\n")}}, splitBoots([]byte("Linux version 5.0\n"), re))
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+)
+
+// Crash classes reported in Report.CrashClass. They are finer than report types
+// for some kinds of crashes and are empty for all other crashes.
+const (
+	ClassHungTask   = "hung_task"
+	ClassRCUStall   = "rcu_stall"
+	ClassSoftLockup = "soft_lockup"
+	ClassOOM        = "oom"
+	ClassPanic      = "panic"
+)
+
+// CrashClasses lists all crash classes.
+var CrashClasses = []string{ClassHungTask, ClassRCUStall, ClassSoftLockup, ClassOOM, ClassPanic}
+
+// crashClassRules map crashes to classes. The title rules are tried before the body
+// rules, the first matching rule wins. Body rules catch reports whose title names
+// the stuck function only (e.g. titles of other OSes) or a consequence of the problem.
+var crashClassRules = []struct {
+	class string
+	title *regexp.Regexp
+	body  *regexp.Regexp
+}{
+	{
+		ClassHungTask,
+		regexp.MustCompile(`^INFO: task (hung|can't die) in `),
+		regexp.MustCompile(`INFO: task .* blocked for more than [0-9]+ seconds`),
+	},
+	{
+		ClassRCUStall,
+		regexp.MustCompile(`\brcu\b.*\bstalls?\b`),
+		regexp.MustCompile(`rcu: INFO: rcu_[a-z]+ (self-)?detected (expedited )?stalls?`),
+	},
+	{
+		ClassSoftLockup,
+		regexp.MustCompile(`\bsoft lockup\b`),
+		regexp.MustCompile(`BUG: soft lockup - CPU#`),
+	},
+	{
+		ClassOOM,
+		regexp.MustCompile(`(?i)\bout of memory\b|\boom\b`),
+		regexp.MustCompile(`invoked oom-killer|Out of memory: Kill(ed)? process`),
+	},
+	{
+		ClassPanic,
+		regexp.MustCompile(`(?i)^(kernel )?panic\b`),
+		nil,
+	},
+}
+
+// crashClass returns the class of a report with the given title and body, or "".
+func crashClass(title string, body []byte) string {
+	for _, rule := range crashClassRules {
+		if rule.title.MatchString(title) {
+			return rule.class
+		}
+	}
+	for _, rule := range crashClassRules {
+		if rule.body != nil && rule.body.Match(body) {
+			return rule.class
+		}
+	}
+	return ""
+}
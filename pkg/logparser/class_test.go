@@ -0,0 +1,44 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrashClass(t *testing.T) {
+	tests := []struct {
+		title string
+		body  string
+		want  string
+	}{
+		{"INFO: task hung in foo", "", ClassHungTask},
+		{"INFO: task can't die in foo", "", ClassHungTask},
+		{"INFO: rcu detected stall in foo", "", ClassRCUStall},
+		{"INFO: rcu detected expedited stalls in foo", "", ClassRCUStall},
+		{"BUG: soft lockup in foo", "", ClassSoftLockup},
+		{"BUG: soft lockup", "", ClassSoftLockup},
+		{"WARNING: kmalloc bug in foo", "", ""},
+		{"out of memory in foo", "", ClassOOM},
+		{"Out of memory: Killed process", "", ClassOOM},
+		{"kernel panic: panic_on_warn set", "", ClassPanic},
+		{"panic: runtime error: index out of range", "", ClassPanic},
+		{"KASAN: use-after-free Read in foo", "", ""},
+		{"WARNING in foo", "", ""},
+		// Title rules take precedence over the body ones.
+		{"INFO: task hung in foo", "Kernel panic - not syncing: hung_task: blocked tasks\n", ClassHungTask},
+		{"INFO: task hung in foo", "rcu: INFO: rcu_preempt detected stalls on CPUs/tasks:\n", ClassHungTask},
+		// Body rules.
+		{"foo", "INFO: task syz-executor.0:1234 blocked for more than 143 seconds.\n", ClassHungTask},
+		{"foo", "rcu: INFO: rcu_sched self-detected stall on CPU\n", ClassRCUStall},
+		{"foo", "watchdog: BUG: soft lockup - CPU#0 stuck for 22s!\n", ClassSoftLockup},
+		{"foo", "syz-executor invoked oom-killer: gfp_mask=0xcc0\n", ClassOOM},
+		{"foo", "Kernel panic - not syncing: Fatal exception\n", ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, crashClass(test.title, []byte(test.body)), test.title)
+	}
+}
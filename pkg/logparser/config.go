@@ -0,0 +1,128 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// loadConfig creates the reporter config for opts.
+func loadConfig(opts *Options, warnings io.Writer) (*mgrconfig.Config, error) {
+	cfg := mgrconfig.DefaultValues()
+	if opts.Config != "" {
+		if err := config.LoadFile(opts.Config, cfg); err != nil {
+			return nil, fmt.Errorf("%v: %w", opts.Config, err)
+		}
+	}
+	if opts.Suppressions != "" {
+		patterns, err := loadSuppressions(opts.Suppressions)
+		if err != nil {
+			return nil, err
+		}
+		// The reporter combines them with the target's built-in suppressions.
+		cfg.Suppressions = append(cfg.Suppressions, patterns...)
+	}
+	optsOS, optsArch, optsVMArch := opts.OS, opts.Arch, opts.VMArch
+	if optsOS == "" {
+		optsOS = targets.Linux
+	}
+	if optsArch == "" {
+		optsArch = runtime.GOARCH
+	}
+	if optsVMArch == "" {
+		optsVMArch = optsArch
+	}
+	targetOS, targetVMArch, targetArch := optsOS, optsVMArch, optsArch
+	if cfg.RawTarget != "" {
+		parts := strings.Split(cfg.RawTarget, "/")
+		if len(parts) != 2 && len(parts) != 3 {
+			return nil, fmt.Errorf("%v: bad target %q, want OS/arch or OS/vmarch/arch",
+				opts.Config, cfg.RawTarget)
+		}
+		targetOS = parts[0]
+		targetVMArch = parts[1]
+		targetArch = parts[len(parts)-1]
+		if opts.OS != "" && opts.OS != targetOS || opts.Arch != "" && opts.Arch != targetArch ||
+			opts.VMArch != "" && opts.VMArch != targetVMArch {
+			overridden := fmt.Sprintf("-os=%v -arch=%v", optsOS, optsArch)
+			if opts.VMArch != "" {
+				overridden += " -vmarch=" + opts.VMArch
+			}
+			if opts.StrictTarget {
+				return nil, fmt.Errorf("%v: target %v conflicts with %v", opts.Config, cfg.RawTarget, overridden)
+			}
+			fmt.Fprintf(warnings, "warning: target %v from config %v overrides %v\n",
+				cfg.RawTarget, opts.Config, overridden)
+		}
+	}
+	sysTarget := targets.Get(targetOS, targetVMArch)
+	if sysTarget == nil {
+		if cfg.RawTarget != "" {
+			return nil, fmt.Errorf("%v: unknown target %v (see -list-targets)", opts.Config, cfg.RawTarget)
+		}
+		return nil, fmt.Errorf("unknown target: %s/%s (%v)", targetOS, targetVMArch, supportedTargets(targetOS))
+	}
+	// The reporter expects the kernel object at a fixed location inside of the obj dir.
+	if opts.KernelObj != "" {
+		cfg.KernelObj = opts.KernelObj
+	}
+	if opts.KernelSrc != "" {
+		cfg.KernelSrc = opts.KernelSrc
+	}
+	if opts.Vmlinux != "" {
+		dir, file := filepath.Split(opts.Vmlinux)
+		if file != sysTarget.KernelObject {
+			return nil, fmt.Errorf("-vmlinux must point to a file named %q for %v",
+				sysTarget.KernelObject, targetOS)
+		}
+		dir = filepath.Clean(dir)
+		if opts.KernelObj != "" && filepath.Clean(opts.KernelObj) != dir {
+			return nil, fmt.Errorf("-vmlinux %v is not located in -kernel-obj %v", opts.Vmlinux, opts.KernelObj)
+		}
+		cfg.KernelObj = dir
+	}
+	cfg.RawTarget = fmt.Sprintf("%s/%s", targetOS, targetVMArch)
+	if targetVMArch != targetArch {
+		cfg.RawTarget += "/" + targetArch
+	}
+	cfg.Derived.TargetOS = targetOS
+	cfg.Derived.TargetArch = targetArch
+	cfg.Derived.TargetVMArch = targetVMArch
+	cfg.Derived.SysTarget = sysTarget
+	cfg.CompleteKernelDirs()
+	if !opts.Maintainers {
+		// The reporter uses the sources only to run get_maintainer.pl, don't do that for every crash
+		// (and don't fail symbolization if the default KernelObj dir has no scripts).
+		cfg.KernelSrc = ""
+	}
+	return cfg, nil
+}
+
+// supportedTargets describes the supported VM architectures of the OS, or the supported OSes
+// if the OS is unknown.
+func supportedTargets(targetOS string) string {
+	var list []string
+	what := "OSes"
+	if arches := targets.List[targetOS]; arches != nil {
+		for arch := range arches {
+			list = append(list, arch)
+		}
+		what = targetOS + " VM architectures"
+	} else {
+		for os := range targets.List {
+			list = append(list, os)
+		}
+	}
+	sort.Strings(list)
+	return fmt.Sprintf("supported %v: %v", what, strings.Join(list, ", "))
+}
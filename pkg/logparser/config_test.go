@@ -0,0 +1,81 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigVMArch(t *testing.T) {
+	cfg, err := loadConfig(&Options{OS: targets.Linux, Arch: targets.I386, VMArch: targets.AMD64}, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/amd64/386", cfg.RawTarget)
+	assert.Equal(t, targets.I386, cfg.TargetArch)
+	assert.Equal(t, targets.AMD64, cfg.TargetVMArch)
+	assert.Equal(t, targets.Get(targets.Linux, targets.AMD64), cfg.SysTarget)
+
+	cfg, err = loadConfig(&Options{OS: targets.Linux, Arch: targets.ARM64}, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/arm64", cfg.RawTarget)
+	assert.Equal(t, targets.ARM64, cfg.TargetVMArch)
+
+	_, err = loadConfig(&Options{OS: targets.Linux, Arch: targets.AMD64, VMArch: "vax"}, io.Discard)
+	assert.ErrorContains(t, err, "unknown target: linux/vax (supported linux VM architectures: 386, amd64,")
+	_, err = loadConfig(&Options{OS: "plan9"}, io.Discard)
+	assert.ErrorContains(t, err, "unknown target: plan9/")
+	assert.ErrorContains(t, err, "(supported OSes: ")
+
+	config := filepath.Join(t.TempDir(), "cfg.json")
+	assert.NoError(t, os.WriteFile(config, []byte(`{"target": "linux/arm64"}`), 0644))
+	warnings := new(bytes.Buffer)
+	cfg, err = loadConfig(&Options{Config: config, VMArch: targets.AMD64}, warnings)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/arm64", cfg.RawTarget)
+	assert.Contains(t, warnings.String(), "overrides -os=linux -arch=")
+	assert.Contains(t, warnings.String(), " -vmarch=amd64\n")
+}
+
+func TestLoadConfigStrictTarget(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "cfg.json")
+	assert.NoError(t, os.WriteFile(config, []byte(`{"target": "linux/arm64"}`), 0644))
+	_, err := loadConfig(&Options{Config: config, Arch: targets.AMD64, StrictTarget: true}, io.Discard)
+	assert.EqualError(t, err, config+": target linux/arm64 conflicts with -os=linux -arch=amd64")
+	// Matching or unset flags are fine.
+	for _, opts := range []*Options{
+		{Config: config, Arch: targets.ARM64, StrictTarget: true},
+		{Config: config, StrictTarget: true},
+	} {
+		cfg, err := loadConfig(opts, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, "linux/arm64", cfg.RawTarget)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "cfg.json")
+	for _, test := range []struct {
+		data string
+		err  string
+	}{
+		{`{"target": `, "failed to parse config file: unexpected EOF"},
+		{`{"targe": "linux/amd64"}`, `unknown field "targe"`},
+		{`{"target": "linux"}`, `bad target "linux", want OS/arch or OS/vmarch/arch`},
+		{`{"target": "linux/amd64/arm64/386"}`, "bad target"},
+		{`{"target": "linux/vax"}`, "unknown target linux/vax (see -list-targets)"},
+	} {
+		assert.NoError(t, os.WriteFile(config, []byte(test.data), 0644))
+		_, err := loadConfig(&Options{Config: config}, io.Discard)
+		assert.ErrorContains(t, err, config+": ", test.data)
+		assert.ErrorContains(t, err, test.err, test.data)
+	}
+	_, err := loadConfig(&Options{Config: config + ".missing"}, io.Discard)
+	assert.ErrorContains(t, err, config+".missing: ")
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+// Corruption codes reported in Report.CorruptionCode for corrupted crashes.
+// Unlike Report.CorruptedReason they are stable and can be aggregated.
+const (
+	// CorruptionMissingFrame: the stack trace has no frames that could be used in the title.
+	CorruptionMissingFrame = "MISSING_FRAME"
+	// CorruptionMissingStack: the report has no stack trace at all.
+	CorruptionMissingStack = "MISSING_STACK"
+	// CorruptionFormatMismatch: the crash title matched, but the rest of the report didn't.
+	CorruptionFormatMismatch = "FORMAT_MISMATCH"
+	// CorruptionCorruptedFormat: the report format is known to be produced by corrupted output.
+	CorruptionCorruptedFormat = "CORRUPTED_FORMAT"
+	// CorruptionCorruptedTitle: the title looks like interleaved output of several crashes.
+	CorruptionCorruptedTitle = "CORRUPTED_TITLE"
+	// CorruptionTruncated: the report is incomplete because the log ends inside of it
+	// (Report.Truncated is set and any of the above but CORRUPTED_FORMAT/CORRUPTED_TITLE applies).
+	CorruptionTruncated = "TRUNCATED"
+	// CorruptionUnknown is used for all other (or empty) reasons.
+	CorruptionUnknown = "UNKNOWN"
+)
+
+// CorruptionCodes lists all corruption codes.
+var CorruptionCodes = []string{CorruptionMissingFrame, CorruptionMissingStack, CorruptionFormatMismatch,
+	CorruptionCorruptedFormat, CorruptionCorruptedTitle, CorruptionTruncated, CorruptionUnknown}
+
+// corruptionCodes maps the reasons produced by pkg/report to corruption codes.
+var corruptionCodes = map[string]string{
+	"extracted no frames":                  CorruptionMissingFrame,
+	"no frames in a stack trace":           CorruptionMissingFrame,
+	"call trace is missed":                 CorruptionMissingStack,
+	"no stack trace in report":             CorruptionMissingStack,
+	"matched title but not report regexp":  CorruptionFormatMismatch,
+	"report format is marked as corrupted": CorruptionCorruptedFormat,
+	"title matches corrupted regexp":       CorruptionCorruptedTitle,
+}
+
+// corruptionCode returns the corruption code of a crash, or "" if it's not corrupted.
+func corruptionCode(corrupted bool, reason string, truncated bool) string {
+	if !corrupted {
+		return ""
+	}
+	code := corruptionCodes[reason]
+	switch code {
+	case "":
+		return CorruptionUnknown
+	case CorruptionMissingFrame, CorruptionMissingStack, CorruptionFormatMismatch:
+		if truncated {
+			return CorruptionTruncated
+		}
+	}
+	return code
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorruptionCode(t *testing.T) {
+	for _, test := range []struct {
+		corrupted bool
+		reason    string
+		truncated bool
+		code      string
+	}{
+		{false, "", false, ""},
+		{false, "", true, ""},
+		{true, "extracted no frames", false, CorruptionMissingFrame},
+		{true, "extracted no frames", true, CorruptionTruncated},
+		{true, "no stack trace in report", false, CorruptionMissingStack},
+		{true, "matched title but not report regexp", true, CorruptionTruncated},
+		{true, "title matches corrupted regexp", true, CorruptionCorruptedTitle},
+		{true, "report format is marked as corrupted", false, CorruptionCorruptedFormat},
+		{true, "", false, CorruptionUnknown},
+		{true, "something new", true, CorruptionUnknown},
+	} {
+		assert.Equal(t, test.code, corruptionCode(test.corrupted, test.reason, test.truncated), "%+v", test)
+	}
+	// All reasons map to codes from the list.
+	for _, code := range corruptionCodes {
+		assert.Contains(t, CorruptionCodes, code)
+	}
+}
+
+func TestParseCorruptionCode(t *testing.T) {
+	const log = "[   10.000000] BUG: KASAN: use-after-free in foo+0x1/0x2\n" +
+		"[   10.000000] Read of size 8 at addr ffff888000000000 by task syz-executor/1\n"
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64})
+	assert.NoError(t, err)
+	parsed, err := parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	crash := parsed.Crashes[0]
+	assert.True(t, crash.Corrupted)
+	assert.True(t, crash.Truncated)
+	assert.NotEmpty(t, crash.CorruptedReason)
+	assert.Equal(t, CorruptionTruncated, crash.CorruptionCode)
+}
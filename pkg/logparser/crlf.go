@@ -0,0 +1,55 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"sort"
+)
+
+var crlf = []byte("\r\n")
+
+// lineMap maps positions in a log whose CRLF line endings were converted to LF
+// to positions in the original log. It holds the positions of the converted line feeds.
+type lineMap []int
+
+// convertCRLF converts CRLF line endings in data to LF, lone CRs are preserved except for
+// a CR at the very end of the log (the log was cut between CR and LF), which becomes a LF.
+// Data without CRLF line endings is returned as is, otherwise the result is a copy of the whole
+// log (the reporter needs a single buffer), so mapping the log into memory doesn't help then.
+func convertCRLF(data []byte) ([]byte, lineMap) {
+	if !bytes.Contains(data, crlf) {
+		return data, nil
+	}
+	res := make([]byte, 0, len(data))
+	var lines lineMap
+	for {
+		idx := bytes.Index(data, crlf)
+		if idx == -1 {
+			break
+		}
+		res = append(res, data[:idx]...)
+		lines = append(lines, len(res))
+		res = append(res, '\n')
+		data = data[idx+2:]
+	}
+	res = append(res, data...)
+	if len(data) != 0 && data[len(data)-1] == '\r' {
+		// Same length, so positions don't change.
+		res[len(res)-1] = '\n'
+	}
+	return res, lines
+}
+
+// origPos returns the position in the original log that corresponds to pos in the converted one.
+// A converted line feed maps to the CR that preceded it, so that a range that ends before
+// the line feed does not include the CR.
+func (lines lineMap) origPos(pos int) int {
+	return pos + sort.SearchInts(lines, pos)
+}
+
+// convertedPos is the inverse of origPos.
+func (lines lineMap) convertedPos(pos int) int {
+	return pos - sort.Search(len(lines), func(i int) bool { return lines[i]+i >= pos })
+}
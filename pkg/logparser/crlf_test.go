@@ -0,0 +1,66 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertCRLF(t *testing.T) {
+	data, lines := convertCRLF([]byte("a\r\nbc\n\r\r\nd"))
+	assert.Equal(t, "a\nbc\n\r\nd", string(data))
+	assert.Equal(t, lineMap{1, 6}, lines)
+	for conv, orig := range []int{0, 1, 3, 4, 5, 6, 7, 9, 10} {
+		assert.Equal(t, orig, lines.origPos(conv), "converted pos %v", conv)
+		assert.Equal(t, conv, lines.convertedPos(orig), "orig pos %v", orig)
+	}
+
+	// The log was cut between CR and LF.
+	data, lines = convertCRLF([]byte("a\r\nb\r"))
+	assert.Equal(t, "a\nb\n", string(data))
+	assert.Equal(t, lineMap{1}, lines)
+	assert.Equal(t, 4, lines.origPos(3))
+
+	data, lines = convertCRLF([]byte("a\nb\n"))
+	assert.Equal(t, "a\nb\n", string(data))
+	assert.Nil(t, lines)
+	assert.Equal(t, 3, lines.origPos(3))
+	assert.Equal(t, 3, lines.convertedPos(3))
+}
+
+func TestParseCRLF(t *testing.T) {
+	const log = "[    0.000000] booting\n" +
+		"\n" +
+		"[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	crlfLog := strings.ReplaceAll(log, "\n", "\r\n")
+	// Mixed line endings: only the boot line ends with LF.
+	mixedLog := strings.Replace(crlfLog, "\r\n", "\n", 1)
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64, Context: 1, RawRange: true})
+	assert.NoError(t, err)
+	want, err := parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Len(t, want.Crashes, 1)
+	for _, data := range []string{crlfLog, mixedLog} {
+		got, err := parser.ParseLog([]byte(data), "")
+		assert.NoError(t, err)
+		assert.Len(t, got.Crashes, 1)
+		crash := got.Crashes[0]
+		// Text fields use LF line endings, positions refer to the original log.
+		assert.Equal(t, want.Crashes[0].Title, crash.Title)
+		assert.Equal(t, want.Crashes[0].Report, crash.Report)
+		assert.Equal(t, want.Crashes[0].ContextBefore, crash.ContextBefore)
+		assert.Equal(t, want.Crashes[0].RawRange, crash.RawRange)
+		assert.True(t, strings.HasPrefix(data[crash.StartPos:], "[   10.000000] WARNING: CPU: 0"),
+			data[crash.StartPos:])
+		assert.Equal(t, strings.ReplaceAll(data[crash.StartPos:crash.EndPos], "\r\n", "\n"), crash.RawRange)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fingerprintFields maps names accepted by -fingerprint-fields to the crash data they denote.
+var fingerprintFields = map[string]func(rep *Report) string{
+	"title": func(rep *Report) string { return rep.Title },
+	"frame": func(rep *Report) string { return rep.Frame },
+	"type":  func(rep *Report) string { return rep.Type },
+}
+
+// parseFingerprintFields parses a comma-separated list of fingerprint fields.
+// The result is sorted, so that the fingerprint does not depend on the order of fields.
+func parseFingerprintFields(list string) ([]string, error) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if fingerprintFields[field] == nil {
+			var known []string
+			for name := range fingerprintFields {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown fingerprint field %q (valid fields: %v)",
+				field, strings.Join(known, ", "))
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// fingerprint returns a stable hex-encoded hash of the given crash fields.
+func fingerprint(rep *Report, fields []string) string {
+	hash := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(hash, "%v=%q\n", field, fingerprintFields[field](rep))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	fields, err := parseFingerprintFields("frame, title,frame")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"frame", "title"}, fields)
+
+	_, err = parseFingerprintFields("title,body")
+	assert.ErrorContains(t, err, `unknown fingerprint field "body"`)
+
+	rep1 := &Report{Title: "WARNING in foo", Frame: "foo", StartPos: 1, SourceFile: "a.log"}
+	rep2 := &Report{Title: "WARNING in foo", Frame: "foo", StartPos: 2, SourceFile: "b.log"}
+	rep3 := &Report{Title: "WARNING in foo", Frame: "bar"}
+	assert.Equal(t, fingerprint(rep1, fields), fingerprint(rep2, fields))
+	assert.NotEqual(t, fingerprint(rep1, fields), fingerprint(rep3, fields))
+	assert.Equal(t, fingerprint(rep1, []string{"title"}), fingerprint(rep3, []string{"title"}))
+	// Fingerprints must be stable across versions of the tool.
+	assert.Equal(t, "dfba7aa67794a81d572f8a77bc9a9de8dc67a2a2ee1044e0cb126117f0c4e8de",
+		fingerprint(rep1, fields))
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+
+	"github.com/ianlancetaylor/demangle"
+)
+
+// StackFrame is a stack trace frame of a report. File and Line are set only if
+// the report is symbolized.
+type StackFrame struct {
+	Func string `json:"func"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// frameRe matches backtrace lines such as
+// "  foo+0x12/0x34 [module]", "  foo+0x12/0x34 fs/foo.c:123" and "  foo fs/foo.c:123 [inline]".
+// Unreliable frames ("? foo+0x12/0x34") don't match.
+var frameRe = regexp.MustCompile(`^[ \t]*(?:\[<(?:0x)?[0-9a-f]+>\][ \t]*)?([a-zA-Z0-9_.]+)` +
+	`(\+0x[0-9a-f]+/0x[0-9a-f]+)?(?: ?\[[a-zA-Z0-9_.]+\])?` +
+	`(?:[ \t]+([^\s:]+):([0-9]+))?(?:[ \t]+\[inline\])?[ \t]*$`)
+
+// parseFrames returns the frames of all stack traces in report in the order of appearance.
+func parseFrames(report []byte) []StackFrame {
+	var frames []StackFrame
+	s := bufio.NewScanner(bytes.NewReader(report))
+	s.Buffer(nil, len(report)+1)
+	for s.Scan() {
+		match := frameRe.FindSubmatch(s.Bytes())
+		if match == nil || match[2] == nil && match[3] == nil {
+			continue
+		}
+		frame := StackFrame{Func: string(match[1]), File: string(match[3])}
+		if match[4] != nil {
+			frame.Line, _ = strconv.Atoi(string(match[4]))
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// demangleFrames demangles C++ and Rust symbols in Frame and Frames of crash (without
+// parameters, like pkg/report titles). Other names are left as is.
+func demangleFrames(crash *Report) {
+	crash.Frame = demangle.Filter(crash.Frame, demangle.NoParams)
+	for i := range crash.Frames {
+		crash.Frames[i].Func = demangle.Filter(crash.Frames[i].Func, demangle.NoParams)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFrames(t *testing.T) {
+	raw := []byte(`WARNING: CPU: 0 PID: 1 at fs/foo.c:10 foo+0x12/0x34
+Modules linked in:
+Call Trace:
+ <TASK>
+ bar+0x56/0x78 [ext4]
+ ? unreliable+0x1/0x2
+ do_syscall_64+0x10/0x20
+ </TASK>
+`)
+	assert.Equal(t, []StackFrame{
+		{Func: "bar"},
+		{Func: "do_syscall_64"},
+	}, parseFrames(raw))
+
+	symbolized := []byte(`Call Trace:
+ __dump_stack lib/dump_stack.c:77 [inline]
+ dump_stack+0x10/0x20 lib/dump_stack.c:113
+ foo_bar+0x1/0x10 drivers/foo/bar.c:200
+`)
+	assert.Equal(t, []StackFrame{
+		{Func: "__dump_stack", File: "lib/dump_stack.c", Line: 77},
+		{Func: "dump_stack", File: "lib/dump_stack.c", Line: 113},
+		{Func: "foo_bar", File: "drivers/foo/bar.c", Line: 200},
+	}, parseFrames(symbolized))
+
+	assert.Empty(t, parseFrames(nil))
+}
+
+func TestDemangleFrames(t *testing.T) {
+	crash := &Report{
+		Frame: "_ZN3foo3barEi",
+		Frames: []StackFrame{
+			{Func: "_ZN3foo3barEi", File: "foo.cc", Line: 10},
+			{Func: "_ZN4core9panicking5panic17h0123456789abcdefE"},
+			{Func: "do_syscall_64"},
+		},
+	}
+	demangleFrames(crash)
+	assert.Equal(t, "foo::bar", crash.Frame)
+	assert.Equal(t, []StackFrame{
+		{Func: "foo::bar", File: "foo.cc", Line: 10},
+		{Func: "core::panicking::panic"},
+		{Func: "do_syscall_64"},
+	}, crash.Frames)
+
+	plain := &Report{Frame: "foo_bar", Frames: []StackFrame{{Func: "foo_bar"}, {Func: "_Zinvalid"}}}
+	demangleFrames(plain)
+	assert.Equal(t, &Report{Frame: "foo_bar", Frames: []StackFrame{{Func: "foo_bar"}, {Func: "_Zinvalid"}}}, plain)
+}
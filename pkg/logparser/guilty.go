@@ -0,0 +1,23 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+)
+
+// guiltyLine returns "file:line" of the first frame in the symbolized report that
+// points to the guilty file, or just the file if no frame contains the line number.
+// The guilty file is chosen by the reporter, which already skips library and
+// common kernel files, so the first frame in it is the most likely culprit.
+func guiltyLine(report []byte, guiltyFile string) string {
+	if guiltyFile == "" {
+		return ""
+	}
+	re := regexp.MustCompile(`(?m)(?:^|[\s(])(` + regexp.QuoteMeta(guiltyFile) + `:[0-9]+)`)
+	if match := re.FindSubmatch(report); match != nil {
+		return string(match[1])
+	}
+	return guiltyFile
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuiltyLine(t *testing.T) {
+	report := []byte(`BUG: KASAN: use-after-free in foo_bar+0x1/0x10
+Call Trace:
+ __dump_stack lib/dump_stack.c:77 [inline]
+ dump_stack+0x10/0x20 lib/dump_stack.c:113
+ kasan_report+0x10/0x20 mm/kasan/report.c:409
+ foo_bar_helper drivers/foo/bar.c:123 [inline]
+ foo_bar+0x1/0x10 drivers/foo/bar.c:200
+ do_syscall_64+0x10/0x20 arch/x86/entry/common.c:290
+`)
+	assert.Equal(t, "drivers/foo/bar.c:123", guiltyLine(report, "drivers/foo/bar.c"))
+	assert.Equal(t, "drivers/foo/baz.c", guiltyLine(report, "drivers/foo/baz.c"))
+	assert.Equal(t, "", guiltyLine(report, ""))
+	// A file whose name is a suffix of another one must not match the longer path.
+	assert.Equal(t, "bar.c", guiltyLine(report, "bar.c"))
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"sort"
+)
+
+// lineIndex converts positions in a log to 1-based line numbers.
+// The positions of line feeds are collected on the first use,
+// so logs without crashes are never scanned.
+type lineIndex struct {
+	data     []byte
+	newlines []int
+	built    bool
+}
+
+func (idx *lineIndex) line(pos int) int {
+	if !idx.built {
+		idx.built = true
+		for off := 0; ; {
+			i := bytes.IndexByte(idx.data[off:], '\n')
+			if i == -1 {
+				break
+			}
+			idx.newlines = append(idx.newlines, off+i)
+			off += i + 1
+		}
+	}
+	return sort.SearchInts(idx.newlines, pos) + 1
+}
+
+// setLines sets the line numbers of the crashes found in data.
+func setLines(crashes []*Report, data []byte) {
+	idx := &lineIndex{data: data}
+	for _, crash := range crashes {
+		crash.LineStart = idx.line(crash.StartPos)
+		crash.LineEnd = idx.line(crash.EndPos)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineIndex(t *testing.T) {
+	idx := &lineIndex{data: []byte("a\nbc\r\n\nd")}
+	for pos, line := range []int{1, 1, 2, 2, 2, 2, 3, 4, 4} {
+		assert.Equal(t, line, idx.line(pos), "pos %v", pos)
+	}
+	assert.Equal(t, 1, (&lineIndex{}).line(0))
+
+	crashes := []*Report{{StartPos: 2, EndPos: 5}, {StartPos: 7, EndPos: 8}}
+	setLines(crashes, idx.data)
+	assert.Equal(t, 2, crashes[0].LineStart)
+	assert.Equal(t, 2, crashes[0].LineEnd)
+	assert.Equal(t, 4, crashes[1].LineStart)
+	assert.Equal(t, 4, crashes[1].LineEnd)
+}
@@ -0,0 +1,462 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package logparser extracts crash reports from kernel logs.
+// It implements the parsing part of syz-logparser and can be used by other tools directly.
+package logparser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys" // register targets
+)
+
+// Options control parsing. The zero value parses only the first crash of linux logs
+// for the host architecture. Options correspond to syz-logparser flags of the same name.
+type Options struct {
+	// OS and Arch are the target of the logs (linux and the host architecture by default).
+	// VMArch is the architecture of the kernel that produced the logs if it differs from Arch
+	// (e.g. amd64 for 386 programs). The target of Config takes precedence.
+	OS     string
+	Arch   string
+	VMArch string
+	// StrictTarget makes a conflict between OS/Arch/VMArch and the target of Config an error.
+	StrictTarget bool
+	// LogFormat is one of LogFormats (LogFormatKernel if empty).
+	LogFormat string
+	// Config is an optional manager config file to reuse parsing settings from.
+	Config string
+	// ReporterPreset is one of ReporterPresets (ReporterPresetDefault if empty).
+	ReporterPreset string
+	// Suppressions is an optional file with additional suppression regexps, one per line.
+	Suppressions string
+	// All requests all crash reports of a log instead of only the first one.
+	All bool
+	// Nth selects only the N-th crash report of a log (1-based, ignored with All).
+	Nth int
+	// Vmlinux and KernelObj are used to symbolize reports, KernelSrc (KernelObj by default)
+	// to find maintainers. Reports are symbolized if Vmlinux, KernelObj or Maintainers is set.
+	Vmlinux   string
+	KernelObj string
+	KernelSrc string
+	// Maintainers requests guilty files and maintainers of crashes.
+	Maintainers bool
+	// FingerprintFields is a comma-separated list of crash fields used to compute
+	// fingerprints (title, frame, type). It's "title,frame" if empty.
+	FingerprintFields string
+	// Context is the number of raw log lines included before and after every crash.
+	Context int
+	// RawRange requests the raw log bytes of every crash range.
+	RawRange bool
+	// NoBody omits report bodies.
+	NoBody bool
+	// MaxBodyBytes truncates longer report bodies (0 means no limit), see truncateBody.
+	MaxBodyBytes int
+	// HexOffsets additionally reports StartPos, EndPos and SkipPos as hex strings.
+	HexOffsets bool
+	// PerBoot splits logs at the lines matching BootRegexp and parses every boot separately.
+	PerBoot bool
+	// BootRegexp is DefaultBootRegexp if empty.
+	BootRegexp string
+	// StripTimestamps removes console timestamps matching TimestampRegexp
+	// from report bodies and context lines.
+	StripTimestamps bool
+	// TimestampRegexp is DefaultTimestampRegexp if empty.
+	TimestampRegexp string
+	// Anonymize redacts IP and MAC addresses, host and user names in report bodies,
+	// context lines and raw ranges.
+	Anonymize bool
+	// AnonymizePatterns is an optional file with additional redaction patterns
+	// (see loadRedactions). It implies Anonymize.
+	AnonymizePatterns string
+	// Demangle demangles C++ and Rust symbols in Frame and Frames
+	// (and thus in fingerprints that include the frame).
+	Demangle bool
+	// Warnings receives non-fatal problems (e.g. symbolization failures). They are discarded if nil.
+	Warnings io.Writer
+}
+
+// Parser extracts crashes from logs. It's safe for concurrent use.
+type Parser struct {
+	opts      Options
+	warnings  io.Writer
+	reporter  *report.Reporter
+	target    string
+	symbolize bool
+	// dropCorrupted is set by ReporterPresetStrict.
+	dropCorrupted bool
+	// fingerprintFields are the crash fields used to compute fingerprints.
+	fingerprintFields []string
+	// bootRe matches boot banners for PerBoot.
+	bootRe *regexp.Regexp
+	// timestampRe strips timestamps from report bodies if set.
+	timestampRe *regexp.Regexp
+	// programs extracts the syz programs executed before the crashes.
+	programs *programExtractor
+	// redactions are applied to crashes with Anonymize.
+	redactions []redaction
+}
+
+// Log holds the crashes extracted from a single log.
+type Log struct {
+	Crashes []*Report
+	// Suppressed is set if the log matches suppression patterns of the target.
+	Suppressed bool
+	// Found is the number of crash reports found in the log before the Nth selection
+	// (not counting the corrupted ones dropped with ReporterPresetStrict).
+	Found int
+	// Timing is the time spent in ParseLog.
+	Timing Timing
+}
+
+// Timing is the time spent in the phases of parsing a log.
+type Timing struct {
+	// Parse is the time spent finding crash reports and extracting information
+	// common to all crashes of the log (e.g. programs).
+	Parse time.Duration
+	// Symbolize is the time spent symbolizing reports.
+	Symbolize time.Duration
+	// Serialize is the time spent converting reports to crashes.
+	Serialize time.Duration
+}
+
+// Parse parses the log read from r and returns the crashes found in it.
+func Parse(r io.Reader, opts *Options) ([]*Report, error) {
+	parser, err := NewParser(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseLog(data, "")
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Crashes, nil
+}
+
+// NewParser loads the config and creates a parser for opts.
+func NewParser(opts *Options) (*Parser, error) {
+	p := &Parser{
+		opts:      *opts,
+		warnings:  opts.Warnings,
+		symbolize: opts.Vmlinux != "" || opts.KernelObj != "" || opts.Maintainers,
+	}
+	if p.warnings == nil {
+		p.warnings = io.Discard
+	}
+	cfg, err := loadConfig(opts, p.warnings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if opts.ReporterPreset != "" && !slices.Contains(ReporterPresets, opts.ReporterPreset) {
+		return nil, fmt.Errorf("unknown -reporter-preset %q (supported: %v)",
+			opts.ReporterPreset, strings.Join(ReporterPresets, ", "))
+	}
+	p.dropCorrupted = applyPreset(opts.ReporterPreset, cfg)
+	p.reporter, err = report.NewReporter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reporter: %w", err)
+	}
+	if opts.LogFormat != "" && !slices.Contains(LogFormats, opts.LogFormat) {
+		return nil, fmt.Errorf("unknown -log-format %q (supported: %v)", opts.LogFormat, strings.Join(LogFormats, ", "))
+	}
+	p.target = cfg.RawTarget
+	p.programs = &programExtractor{os: cfg.TargetOS, arch: cfg.TargetArch, warnings: p.warnings}
+	fields := opts.FingerprintFields
+	if fields == "" {
+		fields = "title,frame"
+	}
+	p.fingerprintFields, err = parseFingerprintFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PerBoot {
+		bootRe := opts.BootRegexp
+		if bootRe == "" {
+			bootRe = DefaultBootRegexp
+		}
+		p.bootRe, err = regexp.Compile(bootRe)
+		if err != nil {
+			return nil, fmt.Errorf("bad -boot-regexp: %w", err)
+		}
+	}
+	if opts.Anonymize || opts.AnonymizePatterns != "" {
+		p.redactions = slices.Clone(defaultRedactions)
+		if opts.AnonymizePatterns != "" {
+			extra, err := loadRedactions(opts.AnonymizePatterns)
+			if err != nil {
+				return nil, err
+			}
+			p.redactions = append(p.redactions, extra...)
+		}
+	}
+	if opts.StripTimestamps {
+		timestampRe := opts.TimestampRegexp
+		if timestampRe == "" {
+			timestampRe = DefaultTimestampRegexp
+		}
+		p.timestampRe, err = regexp.Compile(timestampRe)
+		if err != nil {
+			return nil, fmt.Errorf("bad -timestamp-regexp: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Fingerprint computes the fingerprint of rep from its current fields
+// (e.g. after the title was changed).
+func (p *Parser) Fingerprint(rep *Report) string {
+	return fingerprint(rep, p.fingerprintFields)
+}
+
+// Target returns the target of the parsed logs in the OS/arch form.
+func (p *Parser) Target() string {
+	return p.target
+}
+
+// ParseLog extracts crashes from the log data. source is stored in Report.SourceFile.
+// The crashes don't reference data. CRLF line endings are converted to LF (and the log
+// is preprocessed according to LogFormat) in all text fields of the crashes, while
+// positions refer to data as is.
+func (p *Parser) ParseLog(data []byte, source string) (*Log, error) {
+	start := time.Now()
+	parsed := &Log{
+		Suppressed: report.IsSuppressed(p.reporter, data),
+	}
+	orig := data
+	data, lines := p.preprocess(data)
+	type segmentReport struct {
+		foundReport
+		boot int
+		info *MachineInfo
+	}
+	var reports []segmentReport
+	segments := []logSegment{{0, data}}
+	if p.opts.PerBoot {
+		segments = splitBoots(data, p.bootRe)
+	}
+	// Indices of the reports of a boot follow the reports of all preceding boots.
+	indexBase := 0
+	for bootIndex, segment := range segments {
+		found, total := p.parseReports(segment.data, p.opts.PerBoot)
+		var info *MachineInfo
+		if len(found) != 0 {
+			info = extractMachineInfo(segment.data)
+		}
+		for _, fr := range found {
+			// Make positions relative to the whole log.
+			rep := fr.rep
+			rep.Output = data
+			rep.StartPos += segment.pos
+			rep.EndPos += segment.pos
+			rep.SkipPos += segment.pos
+			fr.index += indexBase
+			reports = append(reports, segmentReport{fr, bootIndex, info})
+		}
+		indexBase += total
+	}
+	parsed.Found = len(reports)
+	// Only the last crash can be cut off by the end of the log.
+	truncated := len(reports) != 0 && isTruncated(data, reports[len(reports)-1].rep.StartPos)
+	last := len(reports) - 1
+	if !p.opts.All && p.opts.Nth > 0 {
+		if p.opts.Nth > len(reports) {
+			return nil, fmt.Errorf("-nth %v requested, but the log contains only %v crash reports",
+				p.opts.Nth, len(reports))
+		}
+		reports = reports[p.opts.Nth-1 : p.opts.Nth]
+		last -= p.opts.Nth - 1
+	}
+	var programs []*prog.LogEntry
+	repro := ReproNone
+	if len(reports) != 0 {
+		programs = p.programs.extract(data)
+		repro = detectRepro(data)
+	}
+	for i, sr := range reports {
+		crash := p.makeCrash(sr.rep, source, sr.info, programs, repro, lines, &parsed.Timing)
+		crash.OriginalIndex = sr.index
+		crash.Truncated = truncated && i == last
+		if crash.Truncated {
+			crash.CorruptionCode = corruptionCode(crash.Corrupted, crash.CorruptedReason, true)
+		}
+		if p.opts.PerBoot {
+			crash.BootIndex = &sr.boot
+		}
+		parsed.Crashes = append(parsed.Crashes, crash)
+	}
+	serializeStart := time.Now()
+	setLines(parsed.Crashes, orig)
+	parsed.Timing.Serialize += time.Since(serializeStart)
+	parsed.Timing.Parse = time.Since(start) - parsed.Timing.Symbolize - parsed.Timing.Serialize
+	return parsed, nil
+}
+
+// ContainsCrash returns whether data contains a crash report.
+func (p *Parser) ContainsCrash(data []byte) bool {
+	return p.reporter.ContainsCrash(data)
+}
+
+// ParseFrom extracts all crashes that start in data at or after pos (regardless of All,
+// Nth and PerBoot) and returns them together with the position right after the last one.
+// OriginalIndex of the crashes counts the reports found from pos.
+func (p *Parser) ParseFrom(data []byte, pos int, source string) ([]*Report, int) {
+	return p.parseFrom(data, pos, source, nil)
+}
+
+// parseFrom implements ParseFrom. prev describes the part of the log before data if it's not nil.
+func (p *Parser) parseFrom(data []byte, pos int, source string, prev *streamContext) ([]*Report, int) {
+	orig := data
+	data, lines := p.preprocess(data)
+	pos = lines.convertedPos(pos)
+	var crashes []*Report
+	programs := p.programs.extract(data)
+	repro := detectRepro(data)
+	info := extractMachineInfo(data)
+	if prev != nil {
+		repro = mergeRepro(prev.repro, repro)
+		info = mergeMachineInfo(prev.info, info)
+	}
+	for index := 0; ; index++ {
+		rep := p.reporter.ParseFrom(data, pos)
+		if rep == nil {
+			break
+		}
+		pos = rep.SkipPos
+		if rep.Corrupted && p.dropCorrupted {
+			continue
+		}
+		crash := p.makeCrash(rep, source, info, programs, repro, lines, nil)
+		crash.OriginalIndex = index
+		if crash.Program == "" && prev != nil {
+			crash.Program = prev.program
+		}
+		crashes = append(crashes, crash)
+	}
+	setLines(crashes, orig)
+	return crashes, lines.origPos(pos)
+}
+
+// makeCrash symbolizes rep (if requested) and converts it to the output form.
+// programs, repro and lines describe the log rep was found in. The time spent
+// is added to timing if it's not nil.
+func (p *Parser) makeCrash(rep *report.Report, source string, info *MachineInfo,
+	programs []*prog.LogEntry, repro string, lines posMap, timing *Timing) *Report {
+	// Sizes refer to the log, so take it before symbolization changes the body.
+	reportBytes := len(rep.Report)
+	start := time.Now()
+	symbolized := start
+	if p.symbolize {
+		if err := p.symbolizeReport(rep); err != nil {
+			fmt.Fprintf(p.warnings, "failed to symbolize report %q: %v\n", rep.Title, err)
+		}
+		symbolized = time.Now()
+	}
+	if timing != nil {
+		defer func() {
+			timing.Symbolize += symbolized.Sub(start)
+			timing.Serialize += time.Since(symbolized)
+		}()
+	}
+	crash := p.serializeReport(rep, source)
+	crash.ReportBytes = reportBytes
+	if p.timestampRe != nil {
+		crash.Report = stripTimestamps(crash.Report, p.timestampRe)
+		crash.ContextBefore = stripTimestamps(crash.ContextBefore, p.timestampRe)
+		crash.ContextAfter = stripTimestamps(crash.ContextAfter, p.timestampRe)
+	}
+	if p.redactions != nil {
+		crash.Report = redact(crash.Report, p.redactions)
+		crash.ContextBefore = redact(crash.ContextBefore, p.redactions)
+		crash.ContextAfter = redact(crash.ContextAfter, p.redactions)
+		crash.RawRange = redact(crash.RawRange, p.redactions)
+	}
+	if p.opts.MaxBodyBytes > 0 {
+		crash.Report, crash.BodyTruncated = truncateBody(crash.Report, p.opts.MaxBodyBytes)
+	}
+	if p.opts.Demangle {
+		demangleFrames(crash)
+	}
+	crash.Fingerprint = fingerprint(crash, p.fingerprintFields)
+	crash.Timestamp = lineTimestamp(rep.Output, rep.StartPos)
+	crash.MachineInfo = info
+	crash.Program = programBefore(programs, rep.StartPos)
+	crash.HasRepro = repro != ReproNone
+	crash.ReproType = repro
+	crash.StartPos = lines.origPos(crash.StartPos)
+	crash.EndPos = lines.origPos(crash.EndPos)
+	crash.SkipPos = lines.origPos(crash.SkipPos)
+	crash.RawRangeBytes = crash.EndPos - crash.StartPos
+	p.setHexOffsets(crash)
+	return crash
+}
+
+// setHexOffsets sets the hex form of the crash positions with HexOffsets.
+func (p *Parser) setHexOffsets(crash *Report) {
+	if p.opts.HexOffsets {
+		crash.StartPosHex = fmt.Sprintf("%#x", crash.StartPos)
+		crash.EndPosHex = fmt.Sprintf("%#x", crash.EndPos)
+		crash.SkipPosHex = fmt.Sprintf("%#x", crash.SkipPos)
+	}
+}
+
+// preprocess converts data to the plain kernel log format expected by the reporter.
+// The returned map maps positions in the converted data back to data.
+func (p *Parser) preprocess(data []byte) ([]byte, posMap) {
+	data, lines := convertCRLF(data)
+	if p.opts.LogFormat != LogFormatAndroid {
+		return data, lines
+	}
+	data, edits := preprocessAndroid(data)
+	return data, posMaps{lines, edits}
+}
+
+// foundReport is a crash report with its index among all crash reports of the log.
+type foundReport struct {
+	rep   *report.Report
+	index int
+}
+
+// parseReports returns the first report in data, or all of them with All and Nth,
+// and the number of all reports in data (only known with needTotal if just the first
+// report is requested). Corrupted reports are skipped with ReporterPresetStrict.
+func (p *Parser) parseReports(data []byte, needTotal bool) (found []foundReport, total int) {
+	first := !p.opts.All && p.opts.Nth == 0
+	if first && !p.dropCorrupted && !needTotal {
+		if rep := p.reporter.Parse(data); rep != nil {
+			return []foundReport{{rep, 0}}, 1
+		}
+		return nil, 0
+	}
+	reps := report.ParseAll(p.reporter, data)
+	for i, rep := range reps {
+		if rep.Corrupted && p.dropCorrupted {
+			continue
+		}
+		found = append(found, foundReport{rep, i})
+		if first {
+			break
+		}
+	}
+	return found, len(reps)
+}
+
+// symbolizeReport symbolizes rep in place. On failure the original report body is preserved.
+func (p *Parser) symbolizeReport(rep *report.Report) error {
+	body := rep.Report
+	if err := p.reporter.Symbolize(rep); err != nil {
+		rep.Report = body
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	const crash = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	log := "[    0.000000] Linux version 6.1.0\n" + crash + "[    0.000000] Linux version 6.1.0\n" + crash
+	opts := &Options{OS: targets.Linux, Arch: targets.AMD64}
+	crashes, err := Parse(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.Len(t, crashes, 1)
+	assert.Equal(t, "WARNING in bar", crashes[0].Title)
+	assert.Equal(t, "WARNING", crashes[0].Type)
+	assert.Equal(t, ReproNone, crashes[0].ReproType)
+	assert.Equal(t, &MachineInfo{KernelVersion: "6.1.0"}, crashes[0].MachineInfo)
+	assert.Nil(t, crashes[0].BootIndex)
+	assert.Empty(t, crashes[0].StartPosHex)
+
+	opts.HexOffsets = true
+	crashes, err = Parse(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%#x", crashes[0].StartPos), crashes[0].StartPosHex)
+	assert.Equal(t, fmt.Sprintf("%#x", crashes[0].EndPos), crashes[0].EndPosHex)
+	assert.Equal(t, fmt.Sprintf("%#x", crashes[0].SkipPos), crashes[0].SkipPosHex)
+	opts.HexOffsets = false
+
+	opts.PerBoot = true
+	opts.StripTimestamps = true
+	crashes, err = Parse(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.Len(t, crashes, 2)
+	for i, crash := range crashes {
+		assert.Equal(t, i, *crash.BootIndex)
+		assert.Contains(t, crash.Report, "\nWARNING: CPU: 0 PID: 1")
+		assert.NotContains(t, crash.Report, "[   10.000000]")
+	}
+	assert.Equal(t, crashes[0].Fingerprint, crashes[1].Fingerprint)
+	// The log ends inside of the second crash.
+	assert.False(t, crashes[0].Truncated)
+	assert.True(t, crashes[1].Truncated)
+
+	parser, err := NewParser(opts)
+	assert.NoError(t, err)
+	parsed, err := parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Positive(t, parsed.Timing.Parse)
+	assert.Positive(t, parsed.Timing.Serialize)
+	assert.Zero(t, parsed.Timing.Symbolize)
+
+	_, err = Parse(strings.NewReader(log), &Options{OS: "bogus"})
+	assert.ErrorContains(t, err, "unknown target: bogus/")
+	_, err = Parse(strings.NewReader(log), &Options{FingerprintFields: "body"})
+	assert.ErrorContains(t, err, `unknown fingerprint field "body"`)
+}
+
+// TestParseTruncated checks that logs truncated at any point (including empty ones) don't cause panics.
+func TestParseTruncated(t *testing.T) {
+	const log = "[    0.000000] Linux version 6.1.0\r\n" +
+		"[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	parser, err := NewParser(&Options{
+		OS:              targets.Linux,
+		Arch:            targets.AMD64,
+		All:             true,
+		Context:         2,
+		RawRange:        true,
+		PerBoot:         true,
+		StripTimestamps: true,
+	})
+	assert.NoError(t, err)
+	for i := 0; i <= len(log); i++ {
+		_, err := parser.ParseLog([]byte(log[:i]), "")
+		assert.NoError(t, err)
+		parser.ParseFrom([]byte(log[:i]), 0, "")
+	}
+}
+
+// TestParseNoTrailingNewline checks that crashes at the end of a log don't depend on whether
+// the last line of the log is terminated.
+func TestParseNoTrailingNewline(t *testing.T) {
+	const warning = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	logs := map[string]string{
+		"complete":     warning + "[   10.000000] ---[ end trace 0000000000000000 ]---",
+		"inside":       warning + "[   10.000000]  qux+0x1/0x2",
+		"at-crash":     "[    1.000000] booting\n[   10.000000] BUG: KASAN: use-after-free in foo+0x1/0x2",
+		"second-crash": warning + "[   10.000000] ---[ end trace 0000000000000000 ]---\n" + warning,
+	}
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64, All: true, Context: 2, RawRange: true})
+	assert.NoError(t, err)
+	for name, log := range logs {
+		log = strings.TrimSuffix(log, "\n")
+		for _, eol := range []string{"\n", "\r\n"} {
+			data := strings.ReplaceAll(log, "\n", eol)
+			want, err := parser.ParseLog([]byte(data+eol), "")
+			assert.NoError(t, err)
+			// Also check a CRLF log that was cut between CR and LF.
+			for _, got := range []string{data, data + eol[:1]} {
+				parsed, err := parser.ParseLog([]byte(got), "")
+				assert.NoError(t, err)
+				assert.Len(t, parsed.Crashes, len(want.Crashes), name)
+				for i, crash := range parsed.Crashes {
+					wantCrash := *want.Crashes[i]
+					// Context lines are raw log lines, the last one is exactly as in the log.
+					wantCrash.ContextAfter = crash.ContextAfter
+					assert.Equal(t, &wantCrash, crash, "%v %q", name, got)
+					assert.LessOrEqual(t, crash.EndPos, len(got))
+					assert.Equal(t, strings.ReplaceAll(got[crash.StartPos:crash.EndPos], "\r\n", "\n"), crash.RawRange)
+				}
+				last := parsed.Crashes[len(parsed.Crashes)-1]
+				assert.Equal(t, name != "complete", last.Truncated, name)
+				assert.True(t, strings.HasSuffix(last.Report, "\n"), name)
+			}
+		}
+	}
+}
+
+// TestParseAllSkipPos documents that report.ParseAll (used with All) resumes parsing at SkipPos
+// of the previous report, which may be inside of it, so one report can produce several crashes.
+func TestParseAllSkipPos(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Kernel panic - not syncing: panic_on_warn set ...\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64})
+	assert.NoError(t, err)
+	reps := report.ParseAll(parser.reporter, []byte(log))
+	assert.Len(t, reps, 2)
+	assert.Equal(t, "WARNING in bar", reps[0].Title)
+	assert.Equal(t, "kernel panic: panic_on_warn set", reps[1].Title)
+	assert.Less(t, reps[0].SkipPos, reps[0].EndPos)
+	assert.GreaterOrEqual(t, reps[1].StartPos, reps[0].SkipPos)
+	next := parser.reporter.ParseFrom([]byte(log), reps[0].SkipPos)
+	assert.Equal(t, reps[1].Title, next.Title)
+	assert.Equal(t, reps[1].StartPos, next.StartPos)
+	assert.Nil(t, parser.reporter.ParseFrom([]byte(log), reps[1].SkipPos))
+}
+
+func TestParseSymbolize(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	// The kernel obj dir has no scripts/get_maintainer.pl, so this also checks
+	// that maintainers are not looked up without Maintainers.
+	vmlinux := buildTestVmlinux(t, "int bar(int x)\n{\n\treturn x + 1;\n}\n")
+	warnings := new(bytes.Buffer)
+	opts := &Options{OS: targets.Linux, Arch: runtime.GOARCH, Vmlinux: vmlinux, Warnings: warnings}
+	parser, err := NewParser(opts)
+	assert.NoError(t, err)
+	parsed, err := parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Empty(t, warnings.String())
+	assert.Len(t, parsed.Crashes, 1)
+	assert.Contains(t, parsed.Crashes[0].Report, "\n bar+0x1/0x2 bar.c:")
+	assert.Empty(t, parsed.Crashes[0].Maintainers)
+	assert.Positive(t, parsed.Timing.Symbolize)
+	// The size is the one of the report in the log, without the added file:line info.
+	plainParser, err := NewParser(&Options{OS: targets.Linux, Arch: runtime.GOARCH})
+	assert.NoError(t, err)
+	plain, err := plainParser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Equal(t, plain.Crashes[0].ReportBytes, parsed.Crashes[0].ReportBytes)
+	assert.Less(t, parsed.Crashes[0].ReportBytes, len(parsed.Crashes[0].Report))
+
+	// If symbolization fails, the unsymbolized body is kept.
+	writeTestScript(t, filepath.Join(filepath.Dir(vmlinux), "scripts", "get_maintainer.pl"), "exit 1")
+	opts.Maintainers = true
+	parser, err = NewParser(opts)
+	assert.NoError(t, err)
+	parsed, err = parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Contains(t, warnings.String(), `failed to symbolize report "WARNING in bar"`)
+	assert.Contains(t, parsed.Crashes[0].Report, "\n bar+0x1/0x2\n")
+
+	_, err = NewParser(&Options{OS: targets.Linux, Arch: runtime.GOARCH, Vmlinux: vmlinux + ".o"})
+	assert.ErrorContains(t, err, `-vmlinux must point to a file named "vmlinux"`)
+	_, err = NewParser(&Options{OS: targets.Linux, Arch: runtime.GOARCH, Vmlinux: vmlinux, KernelObj: t.TempDir()})
+	assert.ErrorContains(t, err, "is not located in -kernel-obj")
+}
+
+func TestParseMaintainers(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	vmlinux := buildTestVmlinux(t, "int bar(int x)\n{\n\treturn x + 1;\n}\n")
+	src := t.TempDir()
+	writeTestScript(t, filepath.Join(src, "scripts", "get_maintainer.pl"),
+		`echo "$@" > args; echo "Foo Bar <foo@example.com> (maintainer:FOO)"`)
+	opts := &Options{OS: targets.Linux, Arch: runtime.GOARCH, Vmlinux: vmlinux, KernelSrc: src, Maintainers: true}
+	parser, err := NewParser(opts)
+	assert.NoError(t, err)
+	parsed, err := parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Len(t, parsed.Crashes, 1)
+	assert.Equal(t, "kernel/foo.c", parsed.Crashes[0].GuiltyFile)
+	assert.Equal(t, []string{"foo@example.com"}, parsed.Crashes[0].Maintainers)
+	args, err := os.ReadFile(filepath.Join(src, "args"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(args), "-f kernel/foo.c")
+
+	// Without KernelSrc the sources are looked up in the kernel obj dir.
+	writeTestScript(t, filepath.Join(filepath.Dir(vmlinux), "scripts", "get_maintainer.pl"),
+		`echo "Baz <baz@example.com> (maintainer:BAZ)"`)
+	opts.KernelSrc = ""
+	parser, err = NewParser(opts)
+	assert.NoError(t, err)
+	parsed, err = parser.ParseLog([]byte(log), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"baz@example.com"}, parsed.Crashes[0].Maintainers)
+}
+
+// buildTestVmlinux compiles the C source to a vmlinux object in a temp dir (the kernel obj dir)
+// and returns its path. The test is skipped if there is no C compiler for the host.
+func buildTestVmlinux(t *testing.T, source string) string {
+	if runtime.GOOS != targets.Linux {
+		t.Skipf("the test is meant to be run only under Linux")
+	}
+	target := targets.Get(targets.Linux, runtime.GOARCH)
+	if target == nil || target.BrokenCompiler != "" {
+		t.Skipf("no C compiler for linux/%v", runtime.GOARCH)
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bar.c")
+	if err := os.WriteFile(src, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vmlinux := filepath.Join(dir, "vmlinux")
+	if out, err := exec.Command(target.CCompiler, "-g", "-O0", "-c", "-o", vmlinux, src).CombinedOutput(); err != nil {
+		t.Skipf("failed to compile the test object: %v\n%s", err, out)
+	}
+	return vmlinux
+}
+
+// writeTestScript writes a shell script with the given commands to path.
+func writeTestScript(t *testing.T, path, commands string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+commands+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseNth(t *testing.T) {
+	warning := func(frame string) string {
+		return "[   10.000000] ------------[ cut here ]------------\n" +
+			"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+			"[   10.000000] Call Trace:\n" +
+			"[   10.000000]  " + frame + "+0x1/0x2\n" +
+			"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	}
+	log := []byte(warning("bar") + warning("baz") + warning("qux"))
+	titles := func(opts Options) ([]string, error) {
+		opts.OS, opts.Arch = targets.Linux, targets.AMD64
+		parser, err := NewParser(&opts)
+		assert.NoError(t, err)
+		parsed, err := parser.ParseLog(log, "")
+		if err != nil {
+			return nil, err
+		}
+		assert.Equal(t, 3, parsed.Found)
+		var res []string
+		for _, crash := range parsed.Crashes {
+			res = append(res, crash.Title)
+		}
+		return res, nil
+	}
+	for _, test := range []struct {
+		opts Options
+		want []string
+	}{
+		{Options{Nth: 1}, []string{"WARNING in bar"}},
+		{Options{Nth: 2}, []string{"WARNING in baz"}},
+		{Options{Nth: 3}, []string{"WARNING in qux"}},
+		// -nth is ignored with -all.
+		{Options{Nth: 5, All: true}, []string{"WARNING in bar", "WARNING in baz", "WARNING in qux"}},
+	} {
+		res, err := titles(test.opts)
+		assert.NoError(t, err, "%+v", test.opts)
+		assert.Equal(t, test.want, res, "%+v", test.opts)
+	}
+	_, err := titles(Options{Nth: 4})
+	assert.EqualError(t, err, "-nth 4 requested, but the log contains only 3 crash reports")
+}
+
+func TestOriginalIndex(t *testing.T) {
+	const boot = "[    0.000000] Linux version 6.1.0\n"
+	// The first boot has a corrupted WARNING (no stack trace) followed by a good one.
+	log := boot +
+		"[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   11.000000] ------------[ cut here ]------------\n" +
+		"[   11.000000] WARNING: CPU: 0 PID: 1 at kernel/qux.c:1 qux+0x1/0x2\n" +
+		"[   11.000000] Call Trace:\n" +
+		"[   11.000000]  bar+0x1/0x2\n" +
+		"[   11.000000] ---[ end trace 0000000000000000 ]---\n" +
+		boot +
+		"[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  baz+0x1/0x2\n" +
+		"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	indices := func(opts Options) []int {
+		opts.OS, opts.Arch = targets.Linux, targets.AMD64
+		parser, err := NewParser(&opts)
+		assert.NoError(t, err)
+		parsed, err := parser.ParseLog([]byte(log), "")
+		assert.NoError(t, err)
+		var res []int
+		for _, crash := range parsed.Crashes {
+			res = append(res, crash.OriginalIndex)
+		}
+		return res
+	}
+	strict := ReporterPresetStrict
+	assert.Equal(t, []int{0}, indices(Options{}))
+	assert.Equal(t, []int{0, 1, 2}, indices(Options{All: true}))
+	assert.Equal(t, []int{2}, indices(Options{Nth: 3}))
+	assert.Equal(t, []int{1, 2}, indices(Options{All: true, ReporterPreset: strict}))
+	assert.Equal(t, []int{1}, indices(Options{ReporterPreset: strict}))
+	// Boots are numbered together.
+	assert.Equal(t, []int{0, 2}, indices(Options{PerBoot: true}))
+	assert.Equal(t, []int{0, 1, 2}, indices(Options{PerBoot: true, All: true}))
+	assert.Equal(t, []int{1, 2}, indices(Options{PerBoot: true, ReporterPreset: strict}))
+
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64, ReporterPreset: strict})
+	assert.NoError(t, err)
+	crashes, _ := parser.ParseFrom([]byte(log), 0, "")
+	assert.Len(t, crashes, 2)
+	assert.Equal(t, 1, crashes[0].OriginalIndex)
+	assert.Equal(t, 2, crashes[1].OriginalIndex)
+	crashes, _ = parser.ParseFrom([]byte(log), crashes[0].SkipPos, "")
+	assert.Len(t, crashes, 1)
+	assert.Equal(t, 0, crashes[0].OriginalIndex)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// MachineInfo describes the machine that produced the log, as far as it can be guessed from the log.
+type MachineInfo struct {
+	KernelVersion string `json:"kernel_version,omitempty"`
+	Arch          string `json:"arch,omitempty"`
+	Hardware      string `json:"hardware,omitempty"`
+}
+
+var (
+	// Boot banner, e.g. "Linux version 6.1.0-rc1 (user@host) (gcc ...) #1 SMP".
+	bannerVersionRe = regexp.MustCompile(`Linux version ([0-9][^\s]*)`)
+	// "CPU: 1 PID: 8377 Comm: syz-executor6 Not tainted 4.12.0-rc7+ #2" and
+	// "CPU: 1 PID: 0 Comm: swapper/1 Tainted: G      D           5.10.2 #10".
+	taintVersionRe = regexp.MustCompile(`(?:Not tainted|Tainted: [A-Z ]+?) +([0-9][^\s]*) #`)
+	hardwareRe     = regexp.MustCompile(`Hardware name: ([^\r\n]+)`)
+	// Register dump lines that are specific to an architecture.
+	archRegs = []struct {
+		arch string
+		re   *regexp.Regexp
+	}{
+		{targets.AMD64, regexp.MustCompile(`\bRIP: [0-9a-f]{4}:`)},
+		{targets.I386, regexp.MustCompile(`\bEIP: [0-9a-f]{4}:`)},
+		{targets.ARM64, regexp.MustCompile(`\bpc : [^\s]+\+0x`)},
+		{targets.ARM, regexp.MustCompile(`\bPC is at `)},
+		{targets.RiscV64, regexp.MustCompile(`\bepc : `)},
+		{targets.PPC64LE, regexp.MustCompile(`\bNIP: +[0-9a-f]+ LR: `)},
+		{targets.S390x, regexp.MustCompile(`\bKrnl PSW : `)},
+	}
+)
+
+// extractMachineInfo does a best-effort extraction of the kernel version, architecture and
+// hardware description from the log. It returns nil if nothing is found.
+func extractMachineInfo(data []byte) *MachineInfo {
+	info := new(MachineInfo)
+	if match := bannerVersionRe.FindSubmatch(data); match != nil {
+		info.KernelVersion = string(match[1])
+	} else if match := taintVersionRe.FindSubmatch(data); match != nil {
+		info.KernelVersion = string(match[1])
+	}
+	if match := hardwareRe.FindSubmatch(data); match != nil {
+		info.Hardware = string(match[1])
+	}
+	for _, reg := range archRegs {
+		if reg.re.Match(data) {
+			info.Arch = reg.arch
+			break
+		}
+	}
+	if *info == (MachineInfo{}) {
+		return nil
+	}
+	return info
+}
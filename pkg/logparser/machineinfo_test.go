@@ -0,0 +1,45 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractMachineInfo(t *testing.T) {
+	for _, test := range []struct {
+		log  string
+		info *MachineInfo
+	}{
+		{
+			log: `[    0.000000] Linux version 6.1.0-rc1 (user@host) (gcc 12) #1 SMP
+[   54.521081][ T3608] CPU: 1 PID: 3608 Comm: syz-executor371 Not tainted 6.0.0-rc7-syzkaller #0
+[   54.521081][ T3608] Hardware name: Google Google Compute Engine/Google Compute Engine, BIOS Google 01/01/2011
+[   54.521081][ T3608] RIP: 0010:default_idle+0x28/0x2e0
+`,
+			info: &MachineInfo{
+				KernelVersion: "6.1.0-rc1",
+				Arch:          "amd64",
+				Hardware:      "Google Google Compute Engine/Google Compute Engine, BIOS Google 01/01/2011",
+			},
+		},
+		{
+			log: `CPU: 1 PID: 0 Comm: swapper/1 Tainted: G      D           5.10.2 #10
+pc : __queue_work+0xa0/0x74c
+`,
+			info: &MachineInfo{
+				KernelVersion: "5.10.2",
+				Arch:          "arm64",
+			},
+		},
+		{
+			log:  "nothing interesting here\n",
+			info: nil,
+		},
+	} {
+		assert.Equal(t, test.info, extractMachineInfo([]byte(test.log)))
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+)
+
+// titleNormalizations are applied in order by NormalizeTitle.
+var titleNormalizations = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// Function offsets: "foo+0x12/0x34" -> "foo".
+	{regexp.MustCompile(`\+0x[0-9a-fA-F]+(?:/0x[0-9a-fA-F]+)?`), ""},
+	// Addresses: "0xffff888012345678", "ffff888012345678".
+	{regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|[0-9a-f]{16})\b`), "ADDR"},
+	// CPU numbers: "CPU#1", "CPU: 1", "cpu 1".
+	{regexp.MustCompile(`(?i)\b(cpu)(?:#|: ?| )[0-9]+\b`), "$1"},
+	// Line numbers of source files: "fs/ext4/inode.c:1234" -> "fs/ext4/inode.c".
+	{regexp.MustCompile(`(\.(?:c|h|S|rs|go)):[0-9]+`), "$1"},
+}
+
+// NormalizeTitle removes version-specific tokens from the title (addresses, CPU numbers
+// and source line numbers), so that titles of the same bug in different kernel versions match.
+func NormalizeTitle(title string) string {
+	for _, norm := range titleNormalizations {
+		title = norm.re.ReplaceAllString(title, norm.repl)
+	}
+	return title
+}
@@ -0,0 +1,24 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	for title, want := range map[string]string{
+		"WARNING in kvm_arch_vcpu_ioctl_run":                       "WARNING in kvm_arch_vcpu_ioctl_run",
+		"kernel BUG at fs/ext4/inode.c:1234!":                      "kernel BUG at fs/ext4/inode.c!",
+		"BUG: unable to handle kernel paging request at 0xffff888": "BUG: unable to handle kernel paging request at ADDR",
+		"general protection fault in foo+0x12/0x340":               "general protection fault in foo",
+		"BUG: soft lockup in bar on CPU#1":                         "BUG: soft lockup in bar on CPU",
+		"INFO: rcu detected stall on cpu 3 at ffffffff81234567":    "INFO: rcu detected stall on cpu at ADDR",
+		"KASAN: slab-use-after-free Read in baz (2)":               "KASAN: slab-use-after-free Read in baz (2)",
+	} {
+		assert.Equal(t, want, NormalizeTitle(title), title)
+	}
+}
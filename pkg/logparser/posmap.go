@@ -0,0 +1,69 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"sort"
+)
+
+// posMap maps positions in a preprocessed log to positions in the log before preprocessing.
+type posMap interface {
+	origPos(pos int) int
+	// convertedPos is the inverse of origPos.
+	convertedPos(pos int) int
+}
+
+// posMaps combines the maps of several preprocessing steps in the order they were applied.
+type posMaps []posMap
+
+func (maps posMaps) origPos(pos int) int {
+	for i := len(maps) - 1; i >= 0; i-- {
+		pos = maps[i].origPos(pos)
+	}
+	return pos
+}
+
+func (maps posMaps) convertedPos(pos int) int {
+	for _, m := range maps {
+		pos = m.convertedPos(pos)
+	}
+	return pos
+}
+
+// edit is a replacement of origLen bytes at orig in the original log
+// with convLen bytes at conv in the preprocessed one.
+type edit struct {
+	conv    int
+	orig    int
+	convLen int
+	origLen int
+}
+
+// editMap maps positions of a log preprocessed by non-overlapping edits, sorted by position.
+// Positions inside of a replacement map to the start of the replaced bytes.
+type editMap []edit
+
+func (edits editMap) origPos(pos int) int {
+	i := sort.Search(len(edits), func(i int) bool { return edits[i].conv > pos }) - 1
+	if i < 0 {
+		return pos
+	}
+	e := edits[i]
+	if pos == e.conv || pos-e.conv < e.convLen {
+		return e.orig
+	}
+	return e.orig + e.origLen + pos - e.conv - e.convLen
+}
+
+func (edits editMap) convertedPos(pos int) int {
+	i := sort.Search(len(edits), func(i int) bool { return edits[i].orig > pos }) - 1
+	if i < 0 {
+		return pos
+	}
+	e := edits[i]
+	if pos == e.orig || pos-e.orig < e.origLen {
+		return e.conv
+	}
+	return e.conv + e.convLen + pos - e.orig - e.origLen
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"github.com/google/syzkaller/pkg/mgrconfig"
+)
+
+// Reporter presets supported in Options.ReporterPreset.
+const (
+	// ReporterPresetDefault uses the reporter as configured by Config: corrupted
+	// reports are returned (and marked as such), ignores and interests of Config apply.
+	ReporterPresetDefault = "default"
+	// ReporterPresetStrict trades recall for precision: corrupted reports are dropped
+	// by the parser as if they were not in the log.
+	ReporterPresetStrict = "strict"
+	// ReporterPresetLenient trades precision for recall: the ignores and interests of
+	// Config are not passed to the reporter, so the crashes they would skip or
+	// suppress are returned as well.
+	ReporterPresetLenient = "lenient"
+)
+
+// ReporterPresets lists all supported reporter presets.
+var ReporterPresets = []string{ReporterPresetDefault, ReporterPresetStrict, ReporterPresetLenient}
+
+// applyPreset adjusts cfg before the reporter is created from it
+// and returns whether corrupted reports have to be dropped.
+func applyPreset(preset string, cfg *mgrconfig.Config) (dropCorrupted bool) {
+	switch preset {
+	case ReporterPresetStrict:
+		return true
+	case ReporterPresetLenient:
+		cfg.Ignores = nil
+		cfg.Interests = nil
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReporterPreset(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   11.000000] ------------[ cut here ]------------\n" +
+		"[   11.000000] WARNING: CPU: 0 PID: 1 at kernel/qux.c:1 qux+0x1/0x2\n" +
+		"[   11.000000] Call Trace:\n" +
+		"[   11.000000]  bar+0x1/0x2\n" +
+		"[   11.000000]  baz+0x1/0x2\n" +
+		"[   11.000000] ---[ end trace 0000000000000000 ]---\n"
+	cfg := filepath.Join(t.TempDir(), "manager.cfg")
+	assert.NoError(t, os.WriteFile(cfg, []byte(`{"target": "linux/amd64", "ignores": ["kernel/qux\\.c"]}`), 0644))
+	titles := func(opts *Options) []string {
+		parser, err := NewParser(opts)
+		assert.NoError(t, err)
+		parsed, err := parser.ParseLog([]byte(log), "")
+		assert.NoError(t, err)
+		var res []string
+		for _, crash := range parsed.Crashes {
+			if crash.Corrupted {
+				res = append(res, "corrupted: "+crash.Title)
+			} else {
+				res = append(res, crash.Title)
+			}
+		}
+		return res
+	}
+
+	opts := &Options{OS: targets.Linux, Arch: targets.AMD64, All: true}
+	assert.Equal(t, []string{"corrupted: WARNING in corrupted", "WARNING in bar"}, titles(opts))
+	opts.ReporterPreset = ReporterPresetStrict
+	assert.Equal(t, []string{"WARNING in bar"}, titles(opts))
+	opts.All = false
+	assert.Equal(t, []string{"WARNING in bar"}, titles(opts))
+	opts.Nth = 1
+	assert.Equal(t, []string{"WARNING in bar"}, titles(opts))
+
+	parser, err := NewParser(opts)
+	assert.NoError(t, err)
+	crashes, _ := parser.ParseFrom([]byte(log), 0, "")
+	assert.Len(t, crashes, 1)
+	assert.Equal(t, "WARNING in bar", crashes[0].Title)
+
+	// The ignored second oops line becomes part of the first report.
+	opts = &Options{Config: cfg, All: true}
+	assert.Equal(t, []string{"WARNING in bar"}, titles(opts))
+	opts.ReporterPreset = ReporterPresetLenient
+	assert.Equal(t, []string{"corrupted: WARNING in corrupted", "WARNING in bar"}, titles(opts))
+
+	_, err = NewParser(&Options{ReporterPreset: "paranoid"})
+	assert.ErrorContains(t, err, `unknown -reporter-preset "paranoid" (supported: default, strict, lenient)`)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// programMarker precedes every program in syzkaller execution logs.
+var programMarker = []byte("executing program ")
+
+// programExtractor finds syz programs executed in logs.
+type programExtractor struct {
+	os   string
+	arch string
+	// warnings receives the error if the target can't be initialized.
+	warnings io.Writer
+
+	init   sync.Once
+	target *prog.Target
+}
+
+// extract returns all programs in data. The target descriptions are loaded lazily
+// only if data contains any program markers.
+func (pe *programExtractor) extract(data []byte) []*prog.LogEntry {
+	if !bytes.Contains(data, programMarker) {
+		return nil
+	}
+	pe.init.Do(func() {
+		var err error
+		pe.target, err = prog.GetTarget(pe.os, pe.arch)
+		if err != nil {
+			fmt.Fprintf(pe.warnings, "warning: can't extract programs: %v\n", err)
+		}
+	})
+	if pe.target == nil {
+		return nil
+	}
+	return pe.target.ParseLog(data, prog.NonStrict)
+}
+
+// programBefore returns the text of the last program that started executing before pos,
+// or "" if there is none.
+func programBefore(entries []*prog.LogEntry, pos int) string {
+	var last *prog.LogEntry
+	for _, ent := range entries {
+		if ent.Start >= pos {
+			break
+		}
+		last = ent
+	}
+	if last == nil {
+		return ""
+	}
+	return string(last.P.Serialize())
+}
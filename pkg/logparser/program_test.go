@@ -0,0 +1,40 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgramBefore(t *testing.T) {
+	if _, err := prog.GetTarget(targets.Linux, targets.AMD64); err != nil {
+		t.Skipf("descriptions are not generated: %v", err)
+	}
+	log := []byte(`[   10.000000] booting
+2024/01/01 00:00:00 executing program 0:
+getpid()
+[   11.000000] some kernel output
+2024/01/01 00:00:01 executing program 1:
+getuid()
+gettid()
+[   12.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2
+2024/01/01 00:00:02 executing program 0:
+getgid()
+`)
+	pe := &programExtractor{os: targets.Linux, arch: targets.AMD64, warnings: io.Discard}
+	entries := pe.extract(log)
+	assert.Len(t, entries, 3)
+	crash := strings.Index(string(log), "[   12.000000]")
+	assert.Equal(t, "getuid()\ngettid()\n", programBefore(entries, crash))
+	assert.Equal(t, "", programBefore(entries, 0))
+	assert.Equal(t, "getgid()\n", programBefore(entries, len(log)))
+
+	assert.Nil(t, pe.extract([]byte("[   12.000000] WARNING: no programs here\n")))
+}
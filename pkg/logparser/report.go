@@ -0,0 +1,109 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"fmt"
+
+	"github.com/google/syzkaller/pkg/report"
+)
+
+// SchemaVersion is the version of the JSON form of Report (reported by syz-logparser
+// in the -json-envelope output). It's incremented on incompatible changes: when fields
+// are removed or renamed, or change their type or meaning. Adding fields doesn't change it.
+const SchemaVersion = 1
+
+// Report is a crash found in a log.
+type Report struct {
+	Title           string               `json:"title"`
+	AltTitles       []string             `json:"alt_titles,omitempty"`
+	NormalizedTitle string               `json:"normalized_title"`
+	Type            string               `json:"type"`
+	Severity        string               `json:"severity"`
+	CrashClass      string               `json:"crash_class,omitempty"`
+	Frame           string               `json:"frame,omitempty"`
+	Frames          []StackFrame         `json:"frames,omitempty"`
+	StartPos        int                  `json:"start_pos"`
+	EndPos          int                  `json:"end_pos"`
+	SkipPos         int                  `json:"skip_pos"`
+	StartPosHex     string               `json:"start_pos_hex,omitempty"`
+	EndPosHex       string               `json:"end_pos_hex,omitempty"`
+	SkipPosHex      string               `json:"skip_pos_hex,omitempty"`
+	LineStart       int                  `json:"line_start"`
+	LineEnd         int                  `json:"line_end"`
+	OriginalIndex   int                  `json:"original_index"`
+	Suppressed      bool                 `json:"suppressed"`
+	Corrupted       bool                 `json:"corrupted"`
+	CorruptedReason string               `json:"corrupted_reason,omitempty"`
+	CorruptionCode  string               `json:"corruption_code,omitempty"`
+	Truncated       bool                 `json:"truncated"`
+	BodyTruncated   bool                 `json:"body_truncated"`
+	ReportBytes     int                  `json:"report_bytes"`
+	RawRangeBytes   int                  `json:"raw_range_bytes"`
+	Fingerprint     string               `json:"fingerprint"`
+	Executor        *report.ExecutorInfo `json:"executor,omitempty"`
+	Program         string               `json:"program,omitempty"`
+	HasRepro        bool                 `json:"has_repro"`
+	ReproType       string               `json:"repro_type"`
+	GuiltyFile      string               `json:"guilty_file,omitempty"`
+	GuiltyLine      string               `json:"guilty_line,omitempty"`
+	Maintainers     []string             `json:"maintainers,omitempty"`
+	MachineInfo     *MachineInfo         `json:"machine_info,omitempty"`
+	SourceFile      string               `json:"source_file,omitempty"`
+	Sources         []string             `json:"sources,omitempty"`
+	OutputFile      string               `json:"output_file,omitempty"`
+	BootIndex       *int                 `json:"boot_index,omitempty"`
+	Timestamp       *float64             `json:"timestamp,omitempty"`
+	ContextBefore   string               `json:"context_before,omitempty"`
+	ContextAfter    string               `json:"context_after,omitempty"`
+	RawRange        string               `json:"raw_range,omitempty"`
+	Count           int                  `json:"count,omitempty"`
+	Report          string               `json:"report"`
+}
+
+func (p *Parser) serializeReport(rep *report.Report, source string) *Report {
+	var maintainers []string
+	for _, recipient := range rep.Recipients {
+		maintainers = append(maintainers, recipient.Address.Address)
+	}
+	res := &Report{
+		Title:           rep.Title,
+		AltTitles:       rep.AltTitles,
+		NormalizedTitle: NormalizeTitle(rep.Title),
+		Type:            rep.Type.String(),
+		Severity:        severity(rep.Type, rep.Title),
+		CrashClass:      crashClass(rep.Title, rep.Report),
+		Frame:           rep.Frame,
+		Frames:          parseFrames(rep.Report),
+		StartPos:        rep.StartPos,
+		EndPos:          rep.EndPos,
+		SkipPos:         rep.SkipPos,
+		Suppressed:      rep.Suppressed,
+		Corrupted:       rep.Corrupted,
+		CorruptedReason: rep.CorruptedReason,
+		CorruptionCode:  corruptionCode(rep.Corrupted, rep.CorruptedReason, false),
+		Executor:        rep.Executor,
+		GuiltyFile:      rep.GuiltyFile,
+		GuiltyLine:      guiltyLine(rep.Report, rep.GuiltyFile),
+		Maintainers:     maintainers,
+		SourceFile:      source,
+		Report:          string(rep.Report),
+	}
+	if p.opts.NoBody {
+		res.Report = ""
+	}
+	if p.opts.Context > 0 {
+		res.ContextBefore = linesBefore(rep.Output, rep.StartPos, p.opts.Context)
+		res.ContextAfter = linesAfter(rep.Output, rep.EndPos, p.opts.Context)
+	}
+	if p.opts.RawRange {
+		raw, ok := rawRange(rep.Output, rep.StartPos, rep.EndPos)
+		if !ok {
+			fmt.Fprintf(p.warnings, "warning: crash %q has invalid range [%d, %d], raw range is empty\n",
+				rep.Title, rep.StartPos, rep.EndPos)
+		}
+		res.RawRange = raw
+	}
+	return res
+}
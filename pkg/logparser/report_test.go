@@ -0,0 +1,74 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSchemaVersion checks that the JSON fields of the current SchemaVersion are still there.
+// If this fails, the change is incompatible: restore the field or bump SchemaVersion
+// (and update the list and the README).
+func TestSchemaVersion(t *testing.T) {
+	assert.Equal(t, 1, SchemaVersion)
+	schema := map[string]string{
+		"title":            "string",
+		"alt_titles":       "[]string",
+		"normalized_title": "string",
+		"type":             "string",
+		"severity":         "string",
+		"crash_class":      "string",
+		"frame":            "string",
+		"frames":           "[]logparser.StackFrame",
+		"start_pos":        "int",
+		"end_pos":          "int",
+		"skip_pos":         "int",
+		"start_pos_hex":    "string",
+		"end_pos_hex":      "string",
+		"skip_pos_hex":     "string",
+		"line_start":       "int",
+		"line_end":         "int",
+		"original_index":   "int",
+		"suppressed":       "bool",
+		"corrupted":        "bool",
+		"corrupted_reason": "string",
+		"corruption_code":  "string",
+		"truncated":        "bool",
+		"body_truncated":   "bool",
+		"report_bytes":     "int",
+		"raw_range_bytes":  "int",
+		"fingerprint":      "string",
+		"executor":         "*report.ExecutorInfo",
+		"program":          "string",
+		"has_repro":        "bool",
+		"repro_type":       "string",
+		"guilty_file":      "string",
+		"guilty_line":      "string",
+		"maintainers":      "[]string",
+		"machine_info":     "*logparser.MachineInfo",
+		"source_file":      "string",
+		"sources":          "[]string",
+		"output_file":      "string",
+		"boot_index":       "*int",
+		"timestamp":        "*float64",
+		"context_before":   "string",
+		"context_after":    "string",
+		"raw_range":        "string",
+		"count":            "int",
+		"report":           "string",
+	}
+	fields := make(map[string]string)
+	typ := reflect.TypeOf(Report{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		fields[name] = typ.Field(i).Type.String()
+	}
+	for name, want := range schema {
+		assert.Equal(t, want, fields[name], name)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Reproducer types reported in Report.ReproType.
+const (
+	ReproNone = "none"
+	ReproC    = "c"
+	ReproSyz  = "syz"
+)
+
+var (
+	// cReproMarker is the header of C reproducers generated by pkg/csource.
+	cReproMarker = []byte("// autogenerated by syzkaller (https://github.com/google/syzkaller)")
+	// syzReproMarker is the prefix the dashboard adds to syz reproducers.
+	syzReproMarker = []byte("# See https://goo.gl/kgGztJ for information about syzkaller reproducers.")
+	// syzReproOptsRe matches the serialized options line (see csource.Options.Serialize)
+	// that starts syz reproducers saved by syz-manager.
+	syzReproOptsRe = regexp.MustCompile(`(?m)^#\{"[a-z_]+":`)
+)
+
+// detectRepro returns the type of reproducer contained in the log.
+// C reproducers take precedence over syz ones since they are more actionable.
+func detectRepro(data []byte) string {
+	if bytes.Contains(data, cReproMarker) {
+		return ReproC
+	}
+	if bytes.Contains(data, syzReproMarker) || syzReproOptsRe.Match(data) {
+		return ReproSyz
+	}
+	return ReproNone
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectRepro(t *testing.T) {
+	tests := []struct {
+		log  string
+		want string
+	}{
+		{"[   12.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n", ReproNone},
+		{"#{\"threaded\":true,\"repeat\":true}\ngetpid()\n", ReproSyz},
+		{"#{\"procs\":1}\ngetpid()\n", ReproSyz},
+		{"# See https://goo.gl/kgGztJ for information about syzkaller reproducers.\n#{}\ngetpid()\n", ReproSyz},
+		{"// autogenerated by syzkaller (https://github.com/google/syzkaller)\n\n#define _GNU_SOURCE\n", ReproC},
+		{"#{\"threaded\":true}\ngetpid()\n" +
+			"// autogenerated by syzkaller (https://github.com/google/syzkaller)\n", ReproC},
+		{"some text #{\"threaded\":true}\n", ReproNone},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, detectRepro([]byte(test.log)), "log: %q", test.log)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"strings"
+
+	"github.com/google/syzkaller/pkg/report/crash"
+)
+
+// Crash severities reported in Report.Severity.
+const (
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+	SeverityFatal = "fatal"
+)
+
+// Severities lists all severities from the least to the most serious one.
+var Severities = []string{SeverityWarn, SeverityError, SeverityFatal}
+
+// typeSeverity maps report types to severities. Types that are not listed are errors.
+var typeSeverity = map[crash.Type]string{
+	crash.Warning:                 SeverityWarn,
+	crash.Bug:                     SeverityFatal,
+	crash.NullPtrDerefBUG:         SeverityFatal,
+	crash.MemorySafetyBUG:         SeverityFatal,
+	crash.DoS:                     SeverityFatal,
+	crash.KASANInvalidFree:        SeverityFatal,
+	crash.KASANNullPtrDerefRead:   SeverityFatal,
+	crash.KASANNullPtrDerefWrite:  SeverityFatal,
+	crash.KASANRead:               SeverityFatal,
+	crash.KASANUnknown:            SeverityFatal,
+	crash.KASANUseAfterFreeRead:   SeverityFatal,
+	crash.KASANUseAfterFreeWrite:  SeverityFatal,
+	crash.KASANWrite:              SeverityFatal,
+	crash.KFENCEInvalidFree:       SeverityFatal,
+	crash.KFENCEMemoryCorruption:  SeverityFatal,
+	crash.KFENCERead:              SeverityFatal,
+	crash.KFENCEUnknown:           SeverityFatal,
+	crash.KFENCEUseAfterFreeRead:  SeverityFatal,
+	crash.KFENCEUseAfterFreeWrite: SeverityFatal,
+	crash.KFENCEWrite:             SeverityFatal,
+	crash.LostConnection:          SeverityFatal,
+	crash.UnexpectedReboot:        SeverityFatal,
+}
+
+// fatalTitlePrefixes and warnTitlePrefixes classify reports of unknown type by title.
+var (
+	fatalTitlePrefixes = []string{"BUG:", "kernel BUG", "Oops", "kernel panic", "panic:"}
+	warnTitlePrefixes  = []string{"WARNING"}
+)
+
+// severity returns the severity of a report with the given type and title.
+func severity(typ crash.Type, title string) string {
+	if typ != crash.UnknownType {
+		if res := typeSeverity[typ]; res != "" {
+			return res
+		}
+		return SeverityError
+	}
+	for _, prefix := range fatalTitlePrefixes {
+		if strings.HasPrefix(title, prefix) {
+			return SeverityFatal
+		}
+	}
+	for _, prefix := range warnTitlePrefixes {
+		if strings.HasPrefix(title, prefix) {
+			return SeverityWarn
+		}
+	}
+	return SeverityError
+}
@@ -0,0 +1,31 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		typ   crash.Type
+		title string
+		want  string
+	}{
+		{crash.Warning, "WARNING in foo", SeverityWarn},
+		{crash.RefcountWARNING, "WARNING: refcount bug in foo", SeverityError},
+		{crash.LockdepBug, "possible deadlock in foo", SeverityError},
+		{crash.KASANUseAfterFreeRead, "KASAN: use-after-free Read in foo", SeverityFatal},
+		{crash.DoS, "kernel panic: panic_on_warn set", SeverityFatal},
+		{crash.UnknownType, "BUG: stack guard page was hit in foo", SeverityFatal},
+		{crash.UnknownType, "WARNING: kernel stack regs has bad value", SeverityWarn},
+		{crash.UnknownType, "INFO: trying to register non-static key", SeverityError},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, severity(test.typ, test.title), test.title)
+	}
+}
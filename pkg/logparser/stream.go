@@ -0,0 +1,166 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+)
+
+const (
+	// streamCompactSize is the minimum size of the start of a Stream log that is dropped at once.
+	streamCompactSize = 1 << 20
+	// streamMargin is the size of the output kept before the unparsed part of a Stream log:
+	// reports may start a few lines before the line that identifies them. A program that
+	// started executing this far before the end of the dropped part is assumed to be complete.
+	streamMargin = 64 << 10
+)
+
+// Stream parses a growing log (e.g. the console output of a running VM) incrementally.
+// Only the part of the log that was not parsed yet (plus Options.Context lines and some
+// margin before it) is kept in memory; the last program, reproducers and machine info are
+// extracted from the rest before it's dropped. Positions, line numbers and OriginalIndex
+// of the crashes refer to the whole log. A Stream is not safe for concurrent use.
+type Stream struct {
+	p      *Parser
+	source string
+	// data is the log from the offset base on, lines is the number of lines before base.
+	data  []byte
+	base  int
+	lines int
+	// pos is the offset in data up to which crashes were parsed.
+	pos int
+	// checked is the offset in data up to which ContainsCrash found no crashes.
+	checked int
+	// found is the number of crashes found so far.
+	found int
+	// prev describes the dropped part of the log.
+	prev streamContext
+}
+
+// streamContext is what crashes need from the part of the log before them.
+type streamContext struct {
+	program string
+	repro   string
+	info    *MachineInfo
+}
+
+// NewStream returns an empty Stream. source is stored in Report.SourceFile.
+func (p *Parser) NewStream(source string) *Stream {
+	return &Stream{p: p, source: source, prev: streamContext{repro: ReproNone}}
+}
+
+// Write appends data to the log. It never fails.
+func (s *Stream) Write(data []byte) (int, error) {
+	s.data = append(s.data, data...)
+	return len(data), nil
+}
+
+// Size returns the size of the whole log written so far.
+func (s *Stream) Size() int {
+	return s.base + len(s.data)
+}
+
+// ContainsCrash returns whether the unparsed part of the log contains a crash.
+// Only the output written since the previous call (from the start of its last line) is checked.
+func (s *Stream) ContainsCrash() bool {
+	if s.p.ContainsCrash(s.data[lineStart(s.data, max(s.pos, s.checked)):]) {
+		return true
+	}
+	s.checked = len(s.data)
+	s.compact()
+	return false
+}
+
+// Parse returns the crashes that start in the unparsed part of the log (regardless of All,
+// Nth and PerBoot) and marks the log as parsed up to the end of the last one. If there are
+// none (e.g. ContainsCrash matched, but the report could not be parsed), the whole log is
+// marked as parsed.
+func (s *Stream) Parse() []*Report {
+	crashes, pos := s.p.parseFrom(s.data, s.pos, s.source, &s.prev)
+	if len(crashes) == 0 {
+		pos = len(s.data)
+	}
+	s.pos, s.checked = pos, pos
+	for _, crash := range crashes {
+		crash.StartPos += s.base
+		crash.EndPos += s.base
+		crash.SkipPos += s.base
+		crash.LineStart += s.lines
+		crash.LineEnd += s.lines
+		crash.OriginalIndex += s.found
+		s.p.setHexOffsets(crash)
+	}
+	if len(crashes) != 0 {
+		s.found = crashes[len(crashes)-1].OriginalIndex + 1
+	}
+	s.compact()
+	return crashes
+}
+
+// compact drops the start of the log that is no longer needed once it's large enough.
+func (s *Stream) compact() {
+	cut := lineStart(s.data, max(lineStart(s.data, max(s.pos, s.checked))-streamMargin, 0))
+	for i := 0; i < s.p.opts.Context && cut > 0; i++ {
+		cut = lineStart(s.data, cut-1)
+	}
+	if cut < streamCompactSize {
+		return
+	}
+	data, lines := s.p.preprocess(s.data[:cut])
+	programs := s.p.programs.extract(data)
+	if len(programs) != 0 {
+		last := programs[len(programs)-1]
+		if start := lines.origPos(last.Start); cut-start < streamMargin {
+			// The program may still be incomplete, keep it in the log.
+			cut, data, programs = start, data[:last.Start], programs[:len(programs)-1]
+		}
+	}
+	if len(programs) != 0 {
+		s.prev.program = programBefore(programs, len(data))
+	}
+	s.prev.repro = mergeRepro(s.prev.repro, detectRepro(data))
+	s.prev.info = mergeMachineInfo(s.prev.info, extractMachineInfo(data))
+	s.lines += bytes.Count(s.data[:cut], []byte{'\n'})
+	s.base += cut
+	s.data = s.data[:copy(s.data, s.data[cut:])]
+	s.pos = max(s.pos-cut, 0)
+	s.checked = max(s.checked-cut, 0)
+}
+
+// lineStart returns the position of the start of the line containing pos.
+func lineStart(data []byte, pos int) int {
+	return bytes.LastIndexByte(data[:pos], '\n') + 1
+}
+
+// mergeRepro returns the best of two reproducer types.
+func mergeRepro(a, b string) string {
+	if a == ReproC || b == ReproC {
+		return ReproC
+	}
+	if a == ReproSyz || b == ReproSyz {
+		return ReproSyz
+	}
+	return ReproNone
+}
+
+// mergeMachineInfo fills the fields missing in a (found earlier in the log) from b.
+func mergeMachineInfo(a, b *MachineInfo) *MachineInfo {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	res := *a
+	if res.KernelVersion == "" {
+		res.KernelVersion = b.KernelVersion
+	}
+	if res.Arch == "" {
+		res.Arch = b.Arch
+	}
+	if res.Hardware == "" {
+		res.Hardware = b.Hardware
+	}
+	return &res
+}
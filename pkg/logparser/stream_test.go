@@ -0,0 +1,62 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	const crash = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000]  baz+0x1/0x2\n"
+	filler := func(n int) string {
+		var buf strings.Builder
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&buf, "[    1.%06d] %v\n", i, strings.Repeat("x", 80))
+		}
+		return buf.String()
+	}
+	// The banner and the reproducer are dropped from the stream before the crashes are parsed.
+	log := "[    0.000000] Linux version 6.1.0 (user@host) (gcc) #1 SMP\n" +
+		string(cReproMarker) + "\n" + filler(14000) + crash + filler(14000) + crash + filler(100)
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64, All: true, Context: 2,
+		HexOffsets: true})
+	assert.NoError(t, err)
+	s := parser.NewStream("log")
+	var got []*Report
+	pos, maxSize := 0, 0
+	for written := 0; written < len(log); {
+		n := min(len(log)-written, 4000)
+		s.Write([]byte(log[written : written+n]))
+		written += n
+		maxSize = max(maxSize, len(s.data))
+		if !s.ContainsCrash() {
+			continue
+		}
+		// The stream must give the same results as parsing the whole log written so far.
+		want, wantPos := parser.ParseFrom([]byte(log[:written]), pos, "log")
+		for _, crash := range want {
+			crash.OriginalIndex += len(got)
+		}
+		pos = wantPos
+		crashes := s.Parse()
+		assert.Equal(t, want, crashes)
+		got = append(got, crashes...)
+	}
+	assert.Len(t, got, 2)
+	assert.Equal(t, len(log), s.Size())
+	assert.NotZero(t, s.base)
+	assert.Less(t, maxSize, streamCompactSize+streamMargin+10000)
+	assert.Equal(t, ReproC, got[0].ReproType)
+	assert.Equal(t, "6.1.0", got[1].MachineInfo.KernelVersion)
+	assert.Equal(t, []int{1, 2}, []int{got[0].OriginalIndex + 1, got[1].OriginalIndex + 1})
+}
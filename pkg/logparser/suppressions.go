@@ -0,0 +1,38 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// loadSuppressions reads suppression regexps from the file, one per line.
+// Empty lines and lines starting with # are ignored.
+func loadSuppressions(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppressions: %w", err)
+	}
+	var patterns []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		pattern := strings.TrimSpace(s.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%v:%v: bad suppression regexp: %w", file, line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read suppressions: %w", err)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good")
+	assert.NoError(t, os.WriteFile(good, []byte(`# known issues
+WARNING in foo
+
+  BUG: bar [0-9]+  
+`), 0644))
+	patterns, err := loadSuppressions(good)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"WARNING in foo", "BUG: bar [0-9]+"}, patterns)
+
+	bad := filepath.Join(dir, "bad")
+	assert.NoError(t, os.WriteFile(bad, []byte("WARNING in foo\n# comment\nBUG: (bar\n"), 0644))
+	_, err = loadSuppressions(bad)
+	assert.ErrorContains(t, err, bad+":3: bad suppression regexp")
+
+	_, err = loadSuppressions(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"regexp"
+
+	"github.com/google/syzkaller/sys/targets"
+)
+
+var (
+	// Boot banners (and other unmistakable lines) of the supported OSes.
+	osBanners = []struct {
+		os string
+		re *regexp.Regexp
+	}{
+		{targets.Linux, bannerVersionRe},
+		{targets.FreeBSD, regexp.MustCompile(`(?m)^(?:\[[ 0-9.]+\] )?FreeBSD [0-9]+\.[0-9]+-`)},
+		{targets.NetBSD, regexp.MustCompile(`(?m)^(?:\[[ 0-9.]+\] )?NetBSD [0-9]+\.[0-9]+|\) at netbsd:`)},
+		{targets.OpenBSD, regexp.MustCompile(`(?m)^(?:\[[ 0-9.]+\] )?OpenBSD [0-9]+\.[0-9]+`)},
+	}
+	// Compiler of the Linux boot banner, e.g. "(aarch64-linux-gnu-gcc (GCC) 12.2.0".
+	bannerCompilerRe = regexp.MustCompile(`Linux version [^\n]*?\(` +
+		`(x86_64|i[3-6]86|aarch64|arm|riscv64|powerpc64le|s390x)-[a-z-]*gcc`)
+	compilerArches = map[string]string{
+		"x86_64":      targets.AMD64,
+		"i386":        targets.I386,
+		"i486":        targets.I386,
+		"i586":        targets.I386,
+		"i686":        targets.I386,
+		"aarch64":     targets.ARM64,
+		"arm":         targets.ARM,
+		"riscv64":     targets.RiscV64,
+		"powerpc64le": targets.PPC64LE,
+		"s390x":       targets.S390x,
+	}
+	// Kernel source paths of the BSDs, e.g. "/usr/obj/usr/src/amd64.amd64/sys/GENERIC"
+	// or "sys/arch/amd64/amd64/trap.c".
+	bsdArchRe = regexp.MustCompile(`(?:/(amd64|i386|arm64|riscv)\.[a-z0-9]+/sys/|` +
+		`\bsys/arch/(amd64|i386|arm64|riscv64)/)`)
+	// Early boot messages that are specific to an architecture.
+	archBootHints = []struct {
+		arch string
+		re   *regexp.Regexp
+	}{
+		{targets.AMD64, regexp.MustCompile(`\bx86/fpu: `)},
+		{targets.ARM64, regexp.MustCompile(`Booting Linux on physical CPU 0x[0-9a-f]{10} `)},
+		{targets.RiscV64, regexp.MustCompile(`\bSBI specification v[0-9]`)},
+		{targets.S390x, regexp.MustCompile(`\bsetup: Linux is running as a z/VM`)},
+	}
+)
+
+// DetectTarget guesses the OS and architecture of the log from boot banners, register dumps
+// and early boot messages. It returns empty strings unless both are found and form a supported target.
+func DetectTarget(data []byte) (targetOS, arch string) {
+	for _, banner := range osBanners {
+		if banner.re.Match(data) {
+			targetOS = banner.os
+			break
+		}
+	}
+	if targetOS != targets.Linux && targetOS != "" {
+		if match := bsdArchRe.FindSubmatch(data); match != nil {
+			arch = string(match[1]) + string(match[2])
+		}
+		if arch == "riscv" {
+			arch = targets.RiscV64
+		}
+	} else {
+		arch = detectLinuxArch(data)
+		if arch != "" {
+			// Register dumps and boot messages are in the Linux format.
+			targetOS = targets.Linux
+		}
+	}
+	if arch == "" || targets.Get(targetOS, arch) == nil {
+		return "", ""
+	}
+	return targetOS, arch
+}
+
+func detectLinuxArch(data []byte) string {
+	for _, reg := range archRegs {
+		if reg.re.Match(data) {
+			return reg.arch
+		}
+	}
+	if match := bannerCompilerRe.FindSubmatch(data); match != nil {
+		return compilerArches[string(match[1])]
+	}
+	for _, hint := range archBootHints {
+		if hint.re.Match(data) {
+			return hint.arch
+		}
+	}
+	return ""
+}
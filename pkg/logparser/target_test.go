@@ -0,0 +1,73 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectTarget(t *testing.T) {
+	for _, test := range []struct {
+		log    string
+		target string
+	}{
+		{
+			log: `[    0.000000] Linux version 6.1.0 (syzkaller@host) (gcc (Debian 12.2.0-14) 12.2.0) #1 SMP
+[   54.521081][ T3608] RIP: 0010:default_idle+0x28/0x2e0
+`,
+			target: "linux/amd64",
+		},
+		{
+			log:    "[    0.000000] Linux version 6.6.0 (user@host) (aarch64-linux-gnu-gcc (GCC) 12.2.0, GNU ld 2.40) #1\n",
+			target: "linux/arm64",
+		},
+		{
+			log:    "[    0.000000] Booting Linux on physical CPU 0x0000000000 [0x411fd070]\n",
+			target: "linux/arm64",
+		},
+		{
+			log:    "[    0.000000] SBI specification v1.0 detected\n",
+			target: "linux/riscv64",
+		},
+		{
+			log:    "[    1.000000] x86/fpu: Supporting XSAVE feature 0x001: 'x87 floating point registers'\n",
+			target: "linux/amd64",
+		},
+		{
+			log:    "EIP: 0060:[<c1034a1d>] EFLAGS: 00010246 CPU: 0\n",
+			target: "linux/386",
+		},
+		{
+			log: `FreeBSD 14.0-CURRENT #0 main-n250000: Mon Jan  1 00:00:00 UTC 2024
+    root@build:/usr/obj/usr/src/amd64.amd64/sys/GENERIC amd64
+`,
+			target: "freebsd/amd64",
+		},
+		{
+			log: "[ 170.7731952] ptrace_machdep_dorequest() at netbsd:ptrace_machdep_dorequest+0x142 " +
+				"sys/arch/amd64/amd64/process_machdep.c:348\n",
+			target: "netbsd/amd64",
+		},
+		{
+			log: `OpenBSD 7.4-current (GENERIC.MP) #0: Mon Jan  1 00:00:00 UTC 2024
+    root@build:/usr/src/sys/arch/amd64/compile/GENERIC.MP
+`,
+			target: "openbsd/amd64",
+		},
+		// An OS without an architecture, or an unsupported combination is not a guess.
+		{log: "[    0.000000] Linux version 6.1.0 (user@host) (gcc 12) #1 SMP\n"},
+		{log: "OpenBSD 7.4 (GENERIC) #0\n"},
+		{log: "OpenBSD 7.4 (GENERIC) #0\nsys/arch/riscv64/riscv64/trap.c:1\n"},
+		{log: "nothing interesting here\n"},
+	} {
+		targetOS, arch := DetectTarget([]byte(test.log))
+		target := ""
+		if targetOS != "" {
+			target = targetOS + "/" + arch
+		}
+		assert.Equal(t, test.target, target, test.log)
+	}
+}
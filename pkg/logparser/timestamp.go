@@ -0,0 +1,35 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+// lineTimestampRe matches the console timestamp at the beginning of a line, e.g. "[  123.456789]".
+var lineTimestampRe = regexp.MustCompile(`^\[ *([0-9]+\.[0-9]+)\]`)
+
+// lineTimestamp returns the console timestamp (in seconds) of the line of data that contains pos,
+// or nil if the line does not start with a timestamp.
+func lineTimestamp(data []byte, pos int) *float64 {
+	if pos < 0 || pos > len(data) {
+		return nil
+	}
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	line := data[start:]
+	if end := bytes.IndexByte(line, '\n'); end != -1 {
+		line = line[:end]
+	}
+	match := lineTimestampRe.FindSubmatch(line)
+	if match == nil {
+		return nil
+	}
+	ts, err := strconv.ParseFloat(string(match[1]), 64)
+	if err != nil {
+		return nil
+	}
+	return &ts
+}
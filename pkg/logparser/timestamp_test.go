@@ -0,0 +1,27 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineTimestamp(t *testing.T) {
+	data := []byte("[    0.000000] booting\n[  123.456789][ T1234] WARNING: foo\nREPORT:\n[   12.5] bar")
+	ts := func(pos int) any {
+		if res := lineTimestamp(data, pos); res != nil {
+			return *res
+		}
+		return nil
+	}
+	assert.Equal(t, 0.0, ts(0))
+	assert.Equal(t, 0.0, ts(22))
+	assert.Equal(t, 123.456789, ts(23))
+	assert.Equal(t, 123.456789, ts(45))
+	assert.Nil(t, ts(60))
+	assert.Equal(t, 12.5, ts(len(data)))
+	assert.Nil(t, ts(len(data)+1))
+}
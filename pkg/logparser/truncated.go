@@ -0,0 +1,42 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// reportEndMarkers are printed by the kernel at the end of crash reports.
+var reportEndMarkers = [][]byte{
+	[]byte("---[ end trace"),
+	[]byte("---[ end Kernel panic"),
+	[]byte("Kernel Offset:"),
+	[]byte("Rebooting in "),
+}
+
+// sanitizerDelimiterRe matches the lines of '=' that enclose KASAN, KMSAN, KCSAN and UBSAN reports.
+var sanitizerDelimiterRe = regexp.MustCompile(`(?m)={40,}$`)
+
+// isTruncated returns whether the log ends inside of the crash that starts at startPos:
+// there are no end of report markers after startPos (and no closing sanitizer delimiter
+// after the title line; the opening one precedes the title).
+// A missing line feed at the end of the log doesn't matter: logs are often saved without
+// one, and a marker on the last line still means that the report is complete.
+func isTruncated(data []byte, startPos int) bool {
+	tail := data[startPos:]
+	if len(tail) == 0 {
+		return true
+	}
+	for _, marker := range reportEndMarkers {
+		if bytes.Contains(tail, marker) {
+			return false
+		}
+	}
+	// Skip the title line (or the opening delimiter if the crash starts with it).
+	if eol := bytes.IndexByte(tail, '\n'); eol != -1 {
+		return !sanitizerDelimiterRe.Match(tail[eol+1:])
+	}
+	return true
+}
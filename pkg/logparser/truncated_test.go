@@ -0,0 +1,66 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package logparser
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTruncated(t *testing.T) {
+	for _, test := range []struct {
+		log       string
+		truncated bool
+	}{
+		{"", true},
+		{"WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\nCall Trace:\n bar+0x1/0x2\n", true},
+		{"WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n---[ end trace 0000000000000000 ]---\n", false},
+		{"Kernel panic - not syncing: panic_on_warn set ...\n---[ end Kernel panic - not syncing ]---\n", false},
+		{"Kernel panic - not syncing: panic_on_warn set ...\nKernel Offset: disabled\nRebooting in 86400 seconds..", false},
+		{"Kernel panic - not syncing: panic_on_warn set ...\nRebooting in 86400 seconds..\n", false},
+		// The last line of the log is not terminated.
+		{"WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n---[ end trace 0000000000000000 ]---", false},
+		{"WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\nCall Trace:\n bar+0x1/0x2", true},
+		{"WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n---[ end tra", true},
+	} {
+		assert.Equal(t, test.truncated, isTruncated([]byte(test.log), 0), test.log)
+	}
+	// Markers before the crash don't count.
+	log := "---[ end trace 0000000000000000 ]---\nBUG: unable to handle kernel paging request\n"
+	assert.True(t, isTruncated([]byte(log), strings.Index(log, "BUG")))
+}
+
+func TestParseTruncatedSanitizer(t *testing.T) {
+	// A complete KASAN report: the crash starts at the title line after the opening delimiter.
+	data, err := os.ReadFile("../report/testdata/linux/report/740")
+	assert.NoError(t, err)
+	log := data[bytes.Index(data, []byte("\n\n"))+2:]
+	parser, err := NewParser(&Options{OS: targets.Linux, Arch: targets.AMD64})
+	assert.NoError(t, err)
+	for _, test := range []struct {
+		log       []byte
+		truncated bool
+	}{
+		{log, false},
+		{bytes.TrimSuffix(log, []byte("\n")), false},
+		// Cut before the closing delimiter.
+		{log[:bytes.LastIndex(log, []byte("\n==="))+1], true},
+	} {
+		parsed, err := parser.ParseLog(test.log, "")
+		assert.NoError(t, err)
+		if assert.Len(t, parsed.Crashes, 1) {
+			crash := parsed.Crashes[0]
+			assert.Equal(t, "KASAN: slab-use-after-free Read in chrdev_open", crash.Title)
+			assert.Equal(t, test.truncated, crash.Truncated)
+			if !test.truncated {
+				assert.Empty(t, crash.CorruptionCode)
+			}
+		}
+	}
+}
@@ -50,6 +50,50 @@ const (
 	UnexpectedReboot = Type("REBOOT")
 )
 
+// AllTypes lists all known crash types.
+var AllTypes = []Type{
+	UnknownType,
+	// keep-sorted start
+	AtomicSleep,
+	Bug,
+	DoS,
+	Hang,
+	KASANInvalidFree,
+	KASANNullPtrDerefRead,
+	KASANNullPtrDerefWrite,
+	KASANRead,
+	KASANUnknown,
+	KASANUseAfterFreeRead,
+	KASANUseAfterFreeWrite,
+	KASANWrite,
+	KCSANAssert,
+	KCSANDataRace,
+	KCSANUnknown,
+	KFENCEInvalidFree,
+	KFENCEMemoryCorruption,
+	KFENCERead,
+	KFENCEUnknown,
+	KFENCEUseAfterFreeRead,
+	KFENCEUseAfterFreeWrite,
+	KFENCEWrite,
+	KMSANInfoLeak,
+	KMSANUninitValue,
+	KMSANUnknown,
+	KMSANUseAfterFreeRead,
+	LockdepBug,
+	MemoryLeak,
+	MemorySafetyBUG,
+	MemorySafetyUBSAN,
+	NullPtrDerefBUG,
+	RefcountWARNING,
+	UBSAN,
+	Warning,
+	// keep-sorted end
+	LostConnection,
+	SyzFailure,
+	UnexpectedReboot,
+}
+
 func (t Type) String() string {
 	if t == UnknownType {
 		return "UNKNOWN"
@@ -0,0 +1,51 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorCyan  = "\x1b[36m"
+)
+
+// useColor enables ANSI colors in human-readable output.
+var useColor bool
+
+// colorize wraps s into the ANSI color sequence if colors are enabled.
+func colorize(s, color string) string {
+	if !useColor {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// colorEnabled decides whether output written to w should be colorized according to -color.
+func colorEnabled(mode string, w io.Writer) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return isTerminal(w), nil
+	}
+	return false, fmt.Errorf("bad -color value %q, expected auto, always or never", mode)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
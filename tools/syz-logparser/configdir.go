@@ -0,0 +1,85 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+)
+
+// configDir maps targets to the manager configs of -config-dir.
+type configDir struct {
+	dir     string
+	configs map[string]string
+}
+
+// loadConfigDir reads the target of every *.cfg and *.json file in dir.
+// Two configs for the same target are an error.
+func loadConfigDir(dir string) (*configDir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read -config-dir: %w", err)
+	}
+	res := &configDir{dir: dir, configs: make(map[string]string)}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || ext != ".cfg" && ext != ".json" {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		cfg := mgrconfig.DefaultValues()
+		if err := config.LoadFile(file, cfg); err != nil {
+			return nil, fmt.Errorf("%v: %w", file, err)
+		}
+		if cfg.RawTarget == "" {
+			return nil, fmt.Errorf("%v: config has no target", file)
+		}
+		if prev := res.configs[cfg.RawTarget]; prev != "" {
+			return nil, fmt.Errorf("both %v and %v are configs for target %v", prev, file, cfg.RawTarget)
+		}
+		res.configs[cfg.RawTarget] = file
+	}
+	return res, nil
+}
+
+// config returns the config file for target, or "" with a warning if there is none.
+func (d *configDir) config(target string, warnings io.Writer) string {
+	if d == nil {
+		return ""
+	}
+	file := d.configs[target]
+	if file == "" {
+		fmt.Fprintf(warnings, "warning: -config-dir %v has no config for target %v (has %v), using defaults\n",
+			d.dir, target, d.targets())
+	}
+	return file
+}
+
+func (d *configDir) targets() []string {
+	var targets []string
+	for target := range d.configs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// flagTarget formats the target given by -os, -arch and -vmarch like Parser.Target.
+func flagTarget() string {
+	arch, vmarch := *flagArch, *flagVMArch
+	if vmarch == "" {
+		vmarch = arch
+	}
+	target := *flagOS + "/" + vmarch
+	if vmarch != arch {
+		target += "/" + arch
+	}
+	return target
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		file := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		return file
+	}
+	amd64 := write("amd64.cfg", `{"target": "linux/amd64"}`)
+	arm64 := write("arm64.json", "# comment\n"+`{"target": "linux/arm64", "ignores": ["WARNING"]}`)
+	write("README", "not a config")
+	configs, err := loadConfigDir(dir)
+	assert.NoError(t, err)
+	warnings := new(bytes.Buffer)
+	assert.Equal(t, amd64, configs.config("linux/amd64", warnings))
+	assert.Equal(t, arm64, configs.config("linux/arm64", warnings))
+	assert.Empty(t, warnings.String())
+	assert.Equal(t, "", configs.config("linux/riscv64", warnings))
+	assert.Equal(t, "warning: -config-dir "+dir+" has no config for target linux/riscv64"+
+		" (has [linux/amd64 linux/arm64]), using defaults\n", warnings.String())
+	assert.Equal(t, "", (*configDir)(nil).config("linux/amd64", warnings))
+
+	// Parsers of detected targets use the matching config: the arm64 one ignores WARNINGs.
+	parser := newTestParser(t)
+	opts := &logparser.Options{OS: targets.Linux, Arch: targets.AMD64, Warnings: warnings}
+	parser.autoTarget = newAutoTarget(opts, configs, parser.parser, nil)
+	log := write("arm64.log", "[    0.000000] Booting Linux on physical CPU 0x0000000000 [0x411fd070]\n"+
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n"+
+		"[   10.000000] pc : foo+0x1/0x2\n")
+	parsed, err := parser.parseLog(log)
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.crashes)
+
+	write("dup.cfg", `{"target": "linux/arm64"}`)
+	_, err = loadConfigDir(dir)
+	assert.ErrorContains(t, err, "are configs for target linux/arm64")
+	write("dup.cfg", `{"target": "linux/arm64", "unknown": 1}`)
+	_, err = loadConfigDir(dir)
+	assert.ErrorContains(t, err, "dup.cfg: failed to parse config file")
+	write("dup.cfg", `{}`)
+	_, err = loadConfigDir(dir)
+	assert.ErrorContains(t, err, "dup.cfg: config has no target")
+	_, err = loadConfigDir(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "failed to read -config-dir")
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"slices"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// dedupCrashes collapses crashes with the same title and frame into the first occurrence
+// and sets Count to the number of merged crashes.
+func dedupCrashes(crashes []*logparser.Report) []*logparser.Report {
+	type key struct {
+		title string
+		frame string
+	}
+	var res []*logparser.Report
+	seen := make(map[key]*logparser.Report)
+	for _, rep := range crashes {
+		k := key{rep.Title, rep.Frame}
+		if first := seen[k]; first != nil {
+			first.Count++
+			continue
+		}
+		rep.Count = 1
+		seen[k] = rep
+		res = append(res, rep)
+	}
+	return res
+}
+
+// mergeAltTitles collapses crashes that share the title or any of the alt titles into
+// the first occurrence (transitively, so that crashes with titles {A, B} and {B, C} are
+// merged). The shortest of all titles of the merged crashes becomes the title of the first
+// occurrence, the other ones become its alt titles. Count is set as in dedupCrashes.
+func mergeAltTitles(crashes []*logparser.Report) []*logparser.Report {
+	type group struct {
+		first  *logparser.Report
+		titles []string
+		count  int
+		merged bool
+	}
+	var groups []*group
+	byTitle := make(map[string]*group)
+	for _, rep := range crashes {
+		titles := append([]string{rep.Title}, rep.AltTitles...)
+		var target *group
+		for _, title := range titles {
+			g := byTitle[title]
+			if g == nil || g == target {
+				continue
+			}
+			if target == nil {
+				target = g
+				continue
+			}
+			// The crash joins two groups, merge the later one into the earlier one.
+			if slices.Index(groups, g) < slices.Index(groups, target) {
+				target, g = g, target
+			}
+			for _, title := range g.titles {
+				byTitle[title] = target
+			}
+			target.titles = append(target.titles, g.titles...)
+			target.count += g.count
+			g.merged = true
+		}
+		if target == nil {
+			target = &group{first: rep}
+			groups = append(groups, target)
+		}
+		for _, title := range titles {
+			if byTitle[title] == nil {
+				byTitle[title] = target
+				target.titles = append(target.titles, title)
+			}
+		}
+		target.count += max(rep.Count, 1)
+	}
+	var res []*logparser.Report
+	for _, g := range groups {
+		if g.merged {
+			continue
+		}
+		canonical := g.titles[0]
+		for _, title := range g.titles {
+			if len(title) < len(canonical) {
+				canonical = title
+			}
+		}
+		rep := g.first
+		rep.Title = canonical
+		rep.AltTitles = nil
+		for _, title := range g.titles {
+			if title != canonical {
+				rep.AltTitles = append(rep.AltTitles, title)
+			}
+		}
+		rep.Count = g.count
+		res = append(res, rep)
+	}
+	return res
+}
+
+// dedupAcrossLogs collapses crashes with the same fingerprint in all logs into the first
+// occurrence. Count is set to the total number of occurrences (including the ones merged
+// by dedupCrashes) and Sources to the logs the crash was found in. Logs whose crashes
+// were all merged into crashes of preceding logs are dropped.
+func dedupAcrossLogs(logs []*parsedLog) []*parsedLog {
+	seen := make(map[string]*logparser.Report)
+	var res []*parsedLog
+	for _, parsed := range logs {
+		source := parsed.source
+		if source == "" {
+			source = "-"
+		}
+		var crashes []*logparser.Report
+		for _, rep := range parsed.crashes {
+			count := max(rep.Count, 1)
+			first := seen[rep.Fingerprint]
+			if first == nil {
+				rep.Count = count
+				rep.Sources = []string{source}
+				seen[rep.Fingerprint] = rep
+				crashes = append(crashes, rep)
+				continue
+			}
+			first.Count += count
+			if !slices.Contains(first.Sources, source) {
+				first.Sources = append(first.Sources, source)
+			}
+		}
+		if len(parsed.crashes) != 0 && len(crashes) == 0 {
+			continue
+		}
+		parsed.crashes = crashes
+		res = append(res, parsed)
+	}
+	return res
+}
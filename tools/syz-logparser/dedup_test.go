@@ -0,0 +1,73 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupCrashes(t *testing.T) {
+	crashes := []*logparser.Report{
+		{Title: "WARNING in foo", Frame: "foo", StartPos: 1},
+		{Title: "KASAN: use-after-free Read in bar", Frame: "bar", StartPos: 2},
+		{Title: "WARNING in foo", Frame: "foo", StartPos: 3},
+		{Title: "WARNING in foo", Frame: "foo", StartPos: 4},
+	}
+	got := dedupCrashes(crashes)
+	assert.Equal(t, []*logparser.Report{
+		{Title: "WARNING in foo", Frame: "foo", StartPos: 1, Count: 3},
+		{Title: "KASAN: use-after-free Read in bar", Frame: "bar", StartPos: 2, Count: 1},
+	}, got)
+}
+
+func TestDedupAcrossLogs(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Fingerprint: "1", Count: 2},
+			{Title: "WARNING in bar", Fingerprint: "2"},
+		}},
+		{source: "b.log"},
+		{source: "c.log", crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Fingerprint: "1"},
+		}},
+		{source: "", crashes: []*logparser.Report{
+			{Title: "WARNING in bar", Fingerprint: "2"},
+			{Title: "WARNING in baz", Fingerprint: "3"},
+		}},
+	}
+	assert.Equal(t, []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Fingerprint: "1", Count: 3, Sources: []string{"a.log", "c.log"}},
+			{Title: "WARNING in bar", Fingerprint: "2", Count: 2, Sources: []string{"a.log", "-"}},
+		}},
+		{source: "b.log"},
+		{source: "", crashes: []*logparser.Report{
+			{Title: "WARNING in baz", Fingerprint: "3", Count: 1, Sources: []string{"-"}},
+		}},
+	}, dedupAcrossLogs(logs))
+}
+
+func TestMergeAltTitles(t *testing.T) {
+	crashes := []*logparser.Report{
+		{Title: "KASAN: use-after-free Read in foo_bar", AltTitles: []string{"KASAN: use-after-free Read in foo"},
+			StartPos: 1},
+		{Title: "WARNING in baz", StartPos: 2},
+		{Title: "KASAN: slab-use-after-free Read in qux", StartPos: 3},
+		// Joins the groups of the first and the third crash.
+		{Title: "KASAN: use-after-free Read in foo", AltTitles: []string{"KASAN: slab-use-after-free Read in qux"},
+			StartPos: 4},
+		{Title: "WARNING in baz", Count: 2, StartPos: 5},
+	}
+	assert.Equal(t, []*logparser.Report{
+		{Title: "KASAN: use-after-free Read in foo", AltTitles: []string{
+			"KASAN: use-after-free Read in foo_bar",
+			"KASAN: slab-use-after-free Read in qux",
+		}, StartPos: 1, Count: 3},
+		{Title: "WARNING in baz", StartPos: 2, Count: 3},
+	}, mergeAltTitles(crashes))
+	assert.Empty(t, mergeAltTitles(nil))
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// logDiagnostics describes how crashes of a single log were found and filtered (see -v).
+type logDiagnostics struct {
+	size int
+	// boots is the number of boot banners matched by -boot-regexp.
+	boots int
+	// found is the number of crash reports found before filtering.
+	found int
+	// removed is the number of crashes dropped by each filter.
+	removed map[string]int
+	// deduped is the number of crashes merged by -dedup.
+	deduped int
+}
+
+func (diag *logDiagnostics) print(w io.Writer, source string, remaining int) {
+	source = sourceName(source)
+	fmt.Fprintf(w, "%v: %v bytes, %v boot banners, %v crash reports found\n",
+		source, diag.size, diag.boots, diag.found)
+	var names []string
+	for name := range diag.removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var removed []string
+	for _, name := range names {
+		removed = append(removed, fmt.Sprintf("-%v removed %v", name, diag.removed[name]))
+	}
+	if diag.deduped != 0 {
+		removed = append(removed, fmt.Sprintf("-dedup merged %v", diag.deduped))
+	}
+	if len(removed) != 0 {
+		fmt.Fprintf(w, "%v: %v\n", source, strings.Join(removed, ", "))
+	}
+	fmt.Fprintf(w, "%v: %v crashes remain\n", source, remaining)
+}
+
+// sourceName returns the name of the log in diagnostic messages.
+func sourceName(source string) string {
+	if source == "" {
+		return "stdin"
+	}
+	return source
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintDiagnostics(t *testing.T) {
+	buf := new(bytes.Buffer)
+	diag := &logDiagnostics{size: 1000, boots: 2, found: 5}
+	diag.print(buf, "", 5)
+	assert.Equal(t, "stdin: 1000 bytes, 2 boot banners, 5 crash reports found\nstdin: 5 crashes remain\n",
+		buf.String())
+
+	buf.Reset()
+	diag.removed = map[string]int{"type": 2, "exclude-corrupted": 1}
+	diag.deduped = 1
+	diag.print(buf, "a.log", 1)
+	assert.Equal(t, "a.log: 1000 bytes, 2 boot banners, 5 crash reports found\n"+
+		"a.log: -exclude-corrupted removed 1, -type removed 2, -dedup merged 1\n"+
+		"a.log: 1 crashes remain\n", buf.String())
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+// crashDiff holds crashes present in only one of the compared sets of logs or in both.
+// Crashes are keyed by fingerprint, each unique crash is listed once.
+type crashDiff struct {
+	OnlyA  []*logparser.Report `json:"only_a"`
+	OnlyB  []*logparser.Report `json:"only_b"`
+	Common []*logparser.Report `json:"common"`
+}
+
+func diffCrashes(a, b []*parsedLog) *crashDiff {
+	inA, inB := crashesByKey(a), crashesByKey(b)
+	diff := &crashDiff{
+		OnlyA:  []*logparser.Report{},
+		OnlyB:  []*logparser.Report{},
+		Common: []*logparser.Report{},
+	}
+	for _, rep := range uniqueCrashes(a) {
+		if inB[rep.Fingerprint] {
+			diff.Common = append(diff.Common, rep)
+		} else {
+			diff.OnlyA = append(diff.OnlyA, rep)
+		}
+	}
+	for _, rep := range uniqueCrashes(b) {
+		if !inA[rep.Fingerprint] {
+			diff.OnlyB = append(diff.OnlyB, rep)
+		}
+	}
+	return diff
+}
+
+func crashesByKey(logs []*parsedLog) map[string]bool {
+	keys := make(map[string]bool)
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			keys[rep.Fingerprint] = true
+		}
+	}
+	return keys
+}
+
+// uniqueCrashes returns the first crash with each fingerprint in the output order.
+func uniqueCrashes(logs []*parsedLog) []*logparser.Report {
+	var res []*logparser.Report
+	seen := make(map[string]bool)
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			if !seen[rep.Fingerprint] {
+				seen[rep.Fingerprint] = true
+				res = append(res, rep)
+			}
+		}
+	}
+	return res
+}
+
+func emitDiff(w io.Writer, diff *crashDiff, format string) {
+	if format == formatJSON || format == formatJSONL {
+		enc := json.NewEncoder(w)
+		if format == formatJSON {
+			indentJSON(enc)
+		}
+		if err := enc.Encode(diff); err != nil {
+			tool.Fail(err)
+		}
+		return
+	}
+	printDiffSection(w, "Only in A", diff.OnlyA)
+	printDiffSection(w, "Only in B", diff.OnlyB)
+	printDiffSection(w, "Common", diff.Common)
+}
+
+func printDiffSection(w io.Writer, header string, crashes []*logparser.Report) {
+	fmt.Fprintf(w, "%v (%v):\n", header, len(crashes))
+	for _, rep := range crashes {
+		fmt.Fprintf(w, "  %v\n", rep.Title)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCrashes(t *testing.T) {
+	a1 := &logparser.Report{Title: "a", Fingerprint: "1"}
+	a2 := &logparser.Report{Title: "b", Fingerprint: "2"}
+	a3 := &logparser.Report{Title: "b again", Fingerprint: "2"}
+	b1 := &logparser.Report{Title: "b", Fingerprint: "2"}
+	b2 := &logparser.Report{Title: "c", Fingerprint: "3"}
+	diff := diffCrashes(
+		[]*parsedLog{{crashes: []*logparser.Report{a1, a2}}, {crashes: []*logparser.Report{a3}}},
+		[]*parsedLog{{crashes: []*logparser.Report{b1, b2}}},
+	)
+	assert.Equal(t, &crashDiff{
+		OnlyA:  []*logparser.Report{a1},
+		OnlyB:  []*logparser.Report{b2},
+		Common: []*logparser.Report{a2},
+	}, diff)
+
+	buf := new(bytes.Buffer)
+	emitDiff(buf, diff, formatHuman)
+	assert.Equal(t, "Only in A (1):\n  a\nOnly in B (1):\n  c\nCommon (1):\n  b\n", buf.String())
+
+	empty := diffCrashes(nil, nil)
+	buf.Reset()
+	emitDiff(buf, empty, formatJSONL)
+	assert.Equal(t, `{"only_a":[],"only_b":[],"common":[]}`+"\n", buf.String())
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/report/crash"
+)
+
+// failCondition is a single -fail-on condition.
+// Conditions on crash fields hold if any crash satisfies them, count conditions are
+// checked against the total number of crashes.
+type failCondition struct {
+	text  string
+	crash func(rep *logparser.Report) bool
+	total func(count int) bool
+}
+
+// failOnFlag is a repeatable flag that collects -fail-on conditions.
+type failOnFlag []*failCondition
+
+func failOnList(name, usage string) *failOnFlag {
+	res := new(failOnFlag)
+	flag.Var(res, name, usage)
+	return res
+}
+
+func (list *failOnFlag) String() string {
+	var res []string
+	for _, cond := range *list {
+		res = append(res, cond.text)
+	}
+	return strings.Join(res, ", ")
+}
+
+func (list *failOnFlag) Set(value string) error {
+	cond, err := parseFailCondition(value)
+	if err != nil {
+		return err
+	}
+	*list = append(*list, cond)
+	return nil
+}
+
+// failConditionRe splits a condition into the field, the operator and the value.
+var failConditionRe = regexp.MustCompile(`^\s*([a-z]+)\s*(>=|<=|!=|=|>|<|~)\s*(.*?)\s*$`)
+
+// parseFailCondition parses conditions of the form:
+//
+//	type=T      the crash type is T or starts with T- (e.g. type=KASAN matches KASAN-READ)
+//	severity=S  the crash severity is S (>= matches S and more serious severities)
+//	title~RE    the title or an alt title matches the regexp
+//	count>N     the total number of crashes is more than N (also >=, <, <=, =, !=)
+func parseFailCondition(text string) (*failCondition, error) {
+	m := failConditionRe.FindStringSubmatch(text)
+	if m == nil {
+		return nil, fmt.Errorf("bad condition %q (expected e.g. type=KASAN or count>5)", text)
+	}
+	field, op, value := m[1], m[2], m[3]
+	cond := &failCondition{text: text}
+	badOp := fmt.Errorf("bad condition %q: unsupported operator %v for %v", text, op, field)
+	switch field {
+	case "type":
+		if op != "=" {
+			return nil, badOp
+		}
+		if !knownTypePrefix(value) {
+			return nil, fmt.Errorf("bad condition %q: unknown report type %q", text, value)
+		}
+		cond.crash = func(rep *logparser.Report) bool {
+			return rep.Type == value || strings.HasPrefix(rep.Type, value+"-")
+		}
+	case "severity":
+		rank := slices.Index(logparser.Severities, value)
+		if rank == -1 {
+			return nil, fmt.Errorf("bad condition %q: unknown severity %q (valid severities: %v)",
+				text, value, strings.Join(logparser.Severities, ", "))
+		}
+		switch op {
+		case "=":
+			cond.crash = func(rep *logparser.Report) bool { return rep.Severity == value }
+		case ">=":
+			cond.crash = func(rep *logparser.Report) bool {
+				return slices.Index(logparser.Severities, rep.Severity) >= rank
+			}
+		default:
+			return nil, badOp
+		}
+	case "title":
+		if op != "~" {
+			return nil, badOp
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad condition %q: %w", text, err)
+		}
+		cond.crash = func(rep *logparser.Report) bool { return matchesTitle(re, rep) }
+	case "count":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad condition %q: count must be an integer", text)
+		}
+		cmp := map[string]func(int) bool{
+			"=":  func(count int) bool { return count == n },
+			"!=": func(count int) bool { return count != n },
+			">":  func(count int) bool { return count > n },
+			">=": func(count int) bool { return count >= n },
+			"<":  func(count int) bool { return count < n },
+			"<=": func(count int) bool { return count <= n },
+		}
+		if cond.total = cmp[op]; cond.total == nil {
+			return nil, badOp
+		}
+	default:
+		return nil, fmt.Errorf("bad condition %q: unknown field %q (supported: type, severity, title, count)",
+			text, field)
+	}
+	return cond, nil
+}
+
+// knownTypePrefix returns whether name is a report type or a dash-separated prefix of one.
+func knownTypePrefix(name string) bool {
+	for _, typ := range crash.AllTypes {
+		if typ.String() == name || strings.HasPrefix(typ.String(), name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the first condition that holds for the crashes, or nil.
+// Crashes merged by deduplication count as many times as they occurred.
+func (list failOnFlag) match(logs []*parsedLog) *failCondition {
+	return tallyLogs(logs, list).failed()
+}
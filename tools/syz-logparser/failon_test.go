@@ -0,0 +1,83 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailOn(t *testing.T) {
+	kasan := &logparser.Report{Title: "KASAN: use-after-free Read in foo", Type: "KASAN-USE-AFTER-FREE-READ",
+		Severity: logparser.SeverityFatal}
+	warning := &logparser.Report{Title: "WARNING in bar", AltTitles: []string{"WARNING in baz"},
+		Type: "WARNING", Severity: logparser.SeverityWarn, Count: 3}
+	logs := []*parsedLog{{crashes: []*logparser.Report{kasan}}, {crashes: []*logparser.Report{warning}}}
+
+	for cond, want := range map[string]bool{
+		"type=KASAN":                     true,
+		"type=KASAN-USE-AFTER-FREE-READ": true,
+		"type=KASAN-READ":                false,
+		"type=LOCKDEP":                   false,
+		"type = WARNING":                 true,
+		"severity=warn":                  true,
+		"severity=error":                 false,
+		"severity>=error":                true,
+		"title~baz$":                     true,
+		"title~^BUG":                     false,
+		"count>3":                        true,
+		"count>4":                        false,
+		"count>=4":                       true,
+		"count=4":                        true,
+		"count!=4":                       false,
+		"count<1":                        false,
+	} {
+		var list failOnFlag
+		assert.NoError(t, list.Set(cond), cond)
+		assert.Equal(t, want, list.match(logs) != nil, cond)
+	}
+
+	var list failOnFlag
+	assert.NoError(t, list.Set("type=LOCKDEP"))
+	assert.NoError(t, list.Set("count>1"))
+	assert.Equal(t, "count>1", list.match(logs).text)
+	assert.Equal(t, "type=LOCKDEP, count>1", list.String())
+	assert.Nil(t, list.match(nil))
+	// count<N holds for logs without crashes.
+	assert.NoError(t, list.Set("count<1"))
+	assert.Equal(t, "count<1", list.match(nil).text)
+
+	for cond, err := range map[string]string{
+		"KASAN":          "bad condition",
+		"type>KASAN":     "unsupported operator > for type",
+		"type=KASA":      `unknown report type "KASA"`,
+		"severity=bad":   `unknown severity "bad"`,
+		"severity<warn":  "unsupported operator < for severity",
+		"title~(":        "error parsing regexp",
+		"count>many":     "count must be an integer",
+		"count~5":        "unsupported operator ~ for count",
+		"frame=foo_bar":  `unknown field "frame"`,
+		"title=KASAN: x": "unsupported operator = for title",
+	} {
+		_, e := parseFailCondition(cond)
+		assert.ErrorContains(t, e, err, cond)
+	}
+}
+
+func TestFailOnIgnoresLimit(t *testing.T) {
+	logs := []*parsedLog{{crashes: []*logparser.Report{
+		{Title: "WARNING in foo", Type: "WARNING"},
+		{Title: "WARNING in bar", Type: "WARNING"},
+	}}}
+	assert.NoError(t, flagFailOn.Set("count>1"))
+	defer func() { *flagFailOn = nil }()
+	page := limitCrashes(logs, 1)
+	assert.Len(t, page[0].crashes, 1)
+	assert.Nil(t, flagFailOn.match(page))
+	// -limit doesn't change the crashes the exit code is computed for.
+	assert.Len(t, logs[0].crashes, 2)
+	assert.Equal(t, exitFailOn, finalStatus(logs, nil))
+}
@@ -0,0 +1,295 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/report/crash"
+)
+
+// crashFilter drops crashes for which keep returns false.
+type crashFilter struct {
+	name string
+	keep func(rep *logparser.Report) bool
+}
+
+// buildFilters creates filters requested on the command line.
+func buildFilters() ([]crashFilter, error) {
+	var filters []crashFilter
+	if *flagType != "" {
+		types, err := parseTypeList(*flagType)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, crashFilter{
+			name: "type",
+			keep: func(rep *logparser.Report) bool { return types[rep.Type] },
+		})
+	}
+	if *flagClass != "" {
+		classes, err := parseClassList(*flagClass)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, crashFilter{
+			name: "class",
+			keep: func(rep *logparser.Report) bool { return classes[rep.CrashClass] },
+		})
+	}
+	if *flagMinSeverity != "" {
+		minRank := slices.Index(logparser.Severities, *flagMinSeverity)
+		if minRank == -1 {
+			return nil, fmt.Errorf("unknown -min-severity %q (valid severities: %v)",
+				*flagMinSeverity, strings.Join(logparser.Severities, ", "))
+		}
+		filters = append(filters, crashFilter{
+			name: "min-severity",
+			keep: func(rep *logparser.Report) bool {
+				return slices.Index(logparser.Severities, rep.Severity) >= minRank
+			},
+		})
+	}
+	if *flagTitleRegexp != "" {
+		re, err := regexp.Compile(*flagTitleRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("bad -title-regexp: %w", err)
+		}
+		filters = append(filters, crashFilter{
+			name: "title-regexp",
+			keep: func(rep *logparser.Report) bool { return matchesTitle(re, rep) },
+		})
+	}
+	if *flagFrameRegexp != "" {
+		re, err := regexp.Compile(*flagFrameRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("bad -frame-regexp: %w", err)
+		}
+		filters = append(filters, crashFilter{
+			name: "frame-regexp",
+			keep: func(rep *logparser.Report) bool { return rep.Frame != "" && re.MatchString(rep.Frame) },
+		})
+	}
+	if *flagRequireTimestamp {
+		filters = append(filters, crashFilter{
+			name: "require-timestamp",
+			keep: func(rep *logparser.Report) bool { return rep.Timestamp != nil },
+		})
+	}
+	// Crashes without timestamps are kept unless -require-timestamp is given.
+	if isFlagSet("since") {
+		filters = append(filters, crashFilter{
+			name: "since",
+			keep: func(rep *logparser.Report) bool { return rep.Timestamp == nil || *rep.Timestamp >= *flagSince },
+		})
+	}
+	if isFlagSet("until") {
+		filters = append(filters, crashFilter{
+			name: "until",
+			keep: func(rep *logparser.Report) bool { return rep.Timestamp == nil || *rep.Timestamp <= *flagUntil },
+		})
+	}
+	if len(*flagIgnore) != 0 {
+		filters = append(filters, crashFilter{
+			name: "ignore",
+			keep: func(rep *logparser.Report) bool { return !flagIgnore.matchAny(rep.RawRange) },
+		})
+	}
+	if *flagExcludeSuppressed {
+		filters = append(filters, crashFilter{
+			name: "exclude-suppressed",
+			keep: func(rep *logparser.Report) bool { return !rep.Suppressed },
+		})
+	}
+	if *flagRequireRepro {
+		filters = append(filters, crashFilter{
+			name: "require-repro",
+			keep: func(rep *logparser.Report) bool { return rep.HasRepro },
+		})
+	}
+	if *flagExecutorOnly {
+		filters = append(filters, crashFilter{
+			name: "executor-only",
+			keep: func(rep *logparser.Report) bool { return rep.Executor != nil },
+		})
+	}
+	if *flagOnlyNew != "" {
+		known, err := loadKnownFingerprints(*flagOnlyNew)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, crashFilter{
+			name: "only-new",
+			keep: func(rep *logparser.Report) bool { return !known[rep.Fingerprint] },
+		})
+	}
+	// Must go last: the exit status relies on it seeing only crashes that passed all other filters.
+	if *flagExcludeCorrupted {
+		filters = append(filters, crashFilter{
+			name: "exclude-corrupted",
+			keep: func(rep *logparser.Report) bool { return !rep.Corrupted },
+		})
+	}
+	return filters, nil
+}
+
+// regexpListFlag is a repeatable flag that collects regexps.
+type regexpListFlag []*regexp.Regexp
+
+func regexpList(name, usage string) *regexpListFlag {
+	res := new(regexpListFlag)
+	flag.Var(res, name, usage)
+	return res
+}
+
+func (list *regexpListFlag) String() string {
+	var res []string
+	for _, re := range *list {
+		res = append(res, re.String())
+	}
+	return strings.Join(res, ", ")
+}
+
+func (list *regexpListFlag) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	*list = append(*list, re)
+	return nil
+}
+
+func (list *regexpListFlag) matchAny(s string) bool {
+	for _, re := range *list {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTitle returns whether the title or any of the alternative titles match re.
+func matchesTitle(re *regexp.Regexp, rep *logparser.Report) bool {
+	if re.MatchString(rep.Title) {
+		return true
+	}
+	for _, title := range rep.AltTitles {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTypeList parses a comma-separated list of report type names.
+func parseTypeList(list string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	var names []string
+	for _, typ := range crash.AllTypes {
+		known[typ.String()] = true
+		names = append(names, typ.String())
+	}
+	types := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown report type %q (valid types: %v)",
+				name, strings.Join(names, ", "))
+		}
+		types[name] = true
+	}
+	return types, nil
+}
+
+// parseClassList parses a comma-separated list of crash class names.
+func parseClassList(list string) (map[string]bool, error) {
+	classes := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(logparser.CrashClasses, name) {
+			return nil, fmt.Errorf("unknown crash class %q (valid classes: %v)",
+				name, strings.Join(logparser.CrashClasses, ", "))
+		}
+		classes[name] = true
+	}
+	return classes, nil
+}
+
+// filterCrashes returns crashes that pass all filters.
+// If removed is not nil, it is updated with the number of crashes dropped by each filter.
+func filterCrashes(crashes []*logparser.Report, filters []crashFilter, removed map[string]int) []*logparser.Report {
+	var res []*logparser.Report
+next:
+	for _, rep := range crashes {
+		for _, filter := range filters {
+			if !filter.keep(rep) {
+				if removed != nil {
+					removed[filter.name]++
+				}
+				continue next
+			}
+		}
+		res = append(res, rep)
+	}
+	return res
+}
+
+// dropRawRanges clears the raw ranges that were collected only for -ignore
+// (they are emitted only with -raw-range). It's called after filterCrashes.
+func dropRawRanges(crashes []*logparser.Report) {
+	if *flagRawRange {
+		return
+	}
+	for _, rep := range crashes {
+		rep.RawRange = ""
+	}
+}
+
+// skipCrashes drops the first offset crashes in total, counting in the output order.
+// Logs whose crashes were all skipped are dropped. Non-positive offset means no skipping.
+// logs are not modified, so that the exit code can be computed for all crashes.
+func skipCrashes(logs []*parsedLog, offset int) []*parsedLog {
+	if offset <= 0 {
+		return logs
+	}
+	var res []*parsedLog
+	for _, parsed := range logs {
+		skip := min(offset, len(parsed.crashes))
+		offset -= skip
+		if skip != 0 && skip == len(parsed.crashes) {
+			continue
+		}
+		page := *parsed
+		page.crashes = parsed.crashes[skip:]
+		res = append(res, &page)
+	}
+	return res
+}
+
+// limitCrashes keeps at most limit crashes in total, counting in the output order.
+// Logs whose crashes were all cut off are dropped. Non-positive limit means no limit.
+// logs are not modified.
+func limitCrashes(logs []*parsedLog, limit int) []*parsedLog {
+	if limit <= 0 {
+		return logs
+	}
+	var res []*parsedLog
+	for _, parsed := range logs {
+		if limit == 0 && len(parsed.crashes) != 0 {
+			continue
+		}
+		page := *parsed
+		if len(page.crashes) > limit {
+			page.crashes = page.crashes[:limit]
+		}
+		limit -= len(page.crashes)
+		res = append(res, &page)
+	}
+	return res
+}
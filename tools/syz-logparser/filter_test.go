@@ -0,0 +1,203 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTypeList(t *testing.T) {
+	types, err := parseTypeList("KASAN-READ, LOCKDEP,UNKNOWN")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"KASAN-READ": true, "LOCKDEP": true, "UNKNOWN": true}, types)
+
+	_, err = parseTypeList("KASAN")
+	assert.ErrorContains(t, err, `unknown report type "KASAN"`)
+}
+
+func TestFilterCrashes(t *testing.T) {
+	crashes := []*logparser.Report{
+		{Title: "a", Type: "KASAN-READ"},
+		{Title: "b", Type: "WARNING"},
+		{Title: "c", Type: "KASAN-READ"},
+	}
+	filters := []crashFilter{{
+		name: "type",
+		keep: func(rep *logparser.Report) bool { return rep.Type == "KASAN-READ" },
+	}}
+	removed := make(map[string]int)
+	got := filterCrashes(crashes, filters, removed)
+	assert.Equal(t, []*logparser.Report{crashes[0], crashes[2]}, got)
+	assert.Equal(t, map[string]int{"type": 1}, removed)
+	assert.Equal(t, crashes, filterCrashes(crashes, nil, nil))
+}
+
+func TestMatchesTitle(t *testing.T) {
+	re := regexp.MustCompile("^KASAN: .* in foo$")
+	assert.True(t, matchesTitle(re, &logparser.Report{Title: "KASAN: use-after-free Read in foo"}))
+	assert.True(t, matchesTitle(re, &logparser.Report{
+		Title:     "general protection fault in bar",
+		AltTitles: []string{"KASAN: null-ptr-deref Read in foo"},
+	}))
+	assert.False(t, matchesTitle(re, &logparser.Report{Title: "WARNING in foo"}))
+}
+
+func TestFrameRegexpFilter(t *testing.T) {
+	*flagFrameRegexp = "^kvm_"
+	defer func() { *flagFrameRegexp = "" }()
+	filters, err := buildFilters()
+	assert.NoError(t, err)
+	crashes := []*logparser.Report{
+		{Title: "WARNING in kvm_arch_vcpu_ioctl_run", Frame: "kvm_arch_vcpu_ioctl_run"},
+		{Title: "WARNING in foo", Frame: "foo"},
+		{Title: "kernel panic: panic_on_warn set"},
+	}
+	assert.Equal(t, crashes[:1], filterCrashes(crashes, filters, nil))
+
+	*flagFrameRegexp = "("
+	_, err = buildFilters()
+	assert.ErrorContains(t, err, "bad -frame-regexp")
+}
+
+func TestExecutorOnlyFilter(t *testing.T) {
+	*flagExecutorOnly = true
+	defer func() { *flagExecutorOnly = false }()
+	filters, err := buildFilters()
+	assert.NoError(t, err)
+	crashes := []*logparser.Report{
+		{Title: "WARNING in foo", Executor: &report.ExecutorInfo{ProcID: 1, ExecID: 2}},
+		{Title: "WARNING in bar"},
+	}
+	assert.Equal(t, crashes[:1], filterCrashes(crashes, filters, nil))
+}
+
+func TestExcludeSuppressedFilter(t *testing.T) {
+	*flagExcludeSuppressed = true
+	defer func() { *flagExcludeSuppressed = false }()
+	filters, err := buildFilters()
+	assert.NoError(t, err)
+	suppressed := &logparser.Report{Title: "lost connection to test machine", Suppressed: true}
+	crash := &logparser.Report{Title: "WARNING in foo"}
+	parsed := &parsedLog{crashes: []*logparser.Report{suppressed, crash}}
+	removed := make(map[string]int)
+	processLog(nil, parsed, filters, removed)
+	assert.Equal(t, []*logparser.Report{crash}, parsed.crashes)
+	assert.Equal(t, map[string]int{"exclude-suppressed": 1}, removed)
+	assert.Equal(t, exitOK, exitStatus([]*parsedLog{parsed}, removed))
+
+	// Dropping suppressed crashes doesn't change the exit code: they never count as found crashes.
+	all := &parsedLog{crashes: []*logparser.Report{suppressed}}
+	parsed = &parsedLog{crashes: []*logparser.Report{suppressed}}
+	removed = make(map[string]int)
+	processLog(nil, parsed, filters, removed)
+	assert.Empty(t, parsed.crashes)
+	assert.Equal(t, exitNoCrashes, exitStatus([]*parsedLog{all}, nil))
+	assert.Equal(t, exitNoCrashes, exitStatus([]*parsedLog{parsed}, removed))
+}
+
+func TestIgnoreFilter(t *testing.T) {
+	assert.NoError(t, flagIgnore.Set("benign"))
+	defer func() { *flagIgnore = nil }()
+	filters, err := buildFilters()
+	assert.NoError(t, err)
+	crashes := []*logparser.Report{
+		{Title: "WARNING in foo", RawRange: "WARNING: benign\n"},
+		{Title: "WARNING in bar", RawRange: "WARNING: bar\n"},
+	}
+	// The filter only selects crashes, the raw ranges are dropped by processLog.
+	assert.Equal(t, crashes[1:], filterCrashes(crashes, filters, nil))
+	assert.Equal(t, "WARNING: bar\n", crashes[1].RawRange)
+	parsed := &parsedLog{crashes: crashes}
+	processLog(nil, parsed, filters, make(map[string]int))
+	assert.Equal(t, []*logparser.Report{{Title: "WARNING in bar"}}, parsed.crashes)
+}
+
+func TestClassFilter(t *testing.T) {
+	*flagClass = "hung_task, rcu_stall"
+	defer func() { *flagClass = "" }()
+	filters, err := buildFilters()
+	assert.NoError(t, err)
+	crashes := []*logparser.Report{
+		{Title: "INFO: task hung in foo", CrashClass: logparser.ClassHungTask},
+		{Title: "kernel panic: panic_on_warn set", CrashClass: logparser.ClassPanic},
+		{Title: "INFO: rcu detected stall in foo", CrashClass: logparser.ClassRCUStall},
+		{Title: "WARNING in foo"},
+	}
+	assert.Equal(t, []*logparser.Report{crashes[0], crashes[2]}, filterCrashes(crashes, filters, nil))
+
+	*flagClass = "hang"
+	_, err = buildFilters()
+	assert.ErrorContains(t, err, `unknown crash class "hang" (valid classes: hung_task, rcu_stall,`)
+}
+
+func TestLimitCrashes(t *testing.T) {
+	a, b, c := &logparser.Report{Title: "a"}, &logparser.Report{Title: "b"}, &logparser.Report{Title: "c"}
+	makeLogs := func() []*parsedLog {
+		return []*parsedLog{
+			{source: "1", crashes: []*logparser.Report{a, b}},
+			{source: "2"},
+			{source: "3", crashes: []*logparser.Report{c}},
+		}
+	}
+	assert.Equal(t, makeLogs(), limitCrashes(makeLogs(), 0))
+	assert.Equal(t, makeLogs(), limitCrashes(makeLogs(), -1))
+	assert.Equal(t, makeLogs(), limitCrashes(makeLogs(), 3))
+	assert.Equal(t, []*parsedLog{
+		{source: "1", crashes: []*logparser.Report{a}},
+		{source: "2"},
+	}, limitCrashes(makeLogs(), 1))
+	assert.Equal(t, []*parsedLog{
+		{source: "1", crashes: []*logparser.Report{a, b}},
+		{source: "2"},
+	}, limitCrashes(makeLogs(), 2))
+}
+
+func TestSkipCrashes(t *testing.T) {
+	a, b, c := &logparser.Report{Title: "a"}, &logparser.Report{Title: "b"}, &logparser.Report{Title: "c"}
+	makeLogs := func() []*parsedLog {
+		return []*parsedLog{
+			{source: "1", crashes: []*logparser.Report{a, b}},
+			{source: "2"},
+			{source: "3", crashes: []*logparser.Report{c}},
+		}
+	}
+	assert.Equal(t, makeLogs(), skipCrashes(makeLogs(), 0))
+	assert.Equal(t, []*parsedLog{
+		{source: "1", crashes: []*logparser.Report{b}},
+		{source: "2"},
+		{source: "3", crashes: []*logparser.Report{c}},
+	}, skipCrashes(makeLogs(), 1))
+	assert.Equal(t, []*parsedLog{
+		{source: "2"},
+		{source: "3", crashes: []*logparser.Report{c}},
+	}, skipCrashes(makeLogs(), 2))
+	assert.Equal(t, []*parsedLog{{source: "2"}}, skipCrashes(makeLogs(), 5))
+	// -offset and -limit select a page.
+	assert.Equal(t, []*parsedLog{
+		{source: "1", crashes: []*logparser.Report{b}},
+		{source: "2"},
+	}, limitCrashes(skipCrashes(makeLogs(), 1), 1))
+	// The exit code describes all parsed crashes, not the page.
+	logs := makeLogs()
+	assert.Equal(t, exitNoCrashes, exitStatus(skipCrashes(logs, 5), nil))
+	assert.Equal(t, makeLogs(), logs)
+	assert.Equal(t, exitOK, exitStatus(logs, nil))
+}
+
+func TestRegexpListFlag(t *testing.T) {
+	var list regexpListFlag
+	assert.NoError(t, list.Set("WARNING: .* at kernel/foo.c"))
+	assert.NoError(t, list.Set("^benign"))
+	assert.Error(t, list.Set("("))
+	assert.Len(t, list, 2)
+	assert.Equal(t, "WARNING: .* at kernel/foo.c, ^benign", list.String())
+	assert.True(t, list.matchAny("[ 1.0] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2"))
+	assert.True(t, list.matchAny("benign warning"))
+	assert.False(t, list.matchAny("not benign"))
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+const (
+	// followPollPeriod is how often the followed file is checked for new output.
+	followPollPeriod = time.Second
+	// followSettleTime is the maximum time we wait for the rest of a crash report
+	// once the beginning of a crash appears in the log (if the log keeps growing).
+	// This is the same approach vm.Monitor uses for console output.
+	followSettleTime = 10 * time.Second
+)
+
+// followState tracks the progress of parsing of a growing log.
+type followState struct {
+	parser *logparser.Parser
+	source string
+	// stream keeps the part of the log that was not reported yet.
+	stream *logparser.Stream
+	// crashSeen is the time when an unreported crash was first noticed (zero if none).
+	crashSeen time.Time
+}
+
+func newFollowState(parser *logparser.Parser, source string) *followState {
+	return &followState{parser: parser, source: source, stream: parser.NewStream(source)}
+}
+
+// follow parses the log at path as it grows and writes every new crash that passes filters
+// as a JSON line (removed is updated with the number of crashes dropped by each filter).
+// It returns the tally of the emitted crashes once SIGINT or SIGTERM is received;
+// crashes that are waiting for the rest of their report are emitted before returning.
+func (p *logParser) follow(w io.Writer, path string, filters []crashFilter,
+	removed map[string]int) (*crashTally, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	ticker := time.NewTicker(followPollPeriod)
+	defer ticker.Stop()
+	enc := json.NewEncoder(w)
+	st := newFollowState(p.parser, path)
+	tally := newCrashTally(*flagFailOn)
+	for stopped := false; ; {
+		grown, err := readAppended(f, st)
+		if err != nil {
+			return tally, fmt.Errorf("failed to read log file: %w", err)
+		}
+		crashes := filterCrashes(st.step(time.Now(), grown, stopped), filters, removed)
+		dropRawRanges(crashes)
+		for _, crash := range crashes {
+			if err := enc.Encode(crash); err != nil {
+				return tally, err
+			}
+		}
+		tally.add(crashes)
+		if stopped {
+			return tally, nil
+		}
+		select {
+		case <-stop:
+			stopped = true
+		case <-ticker.C:
+		}
+	}
+}
+
+// readAppended reads everything appended to f since the previous call.
+// If the file was truncated, it's parsed again from the beginning.
+func readAppended(f *os.File, st *followState) (bool, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if stat.Size() < int64(st.stream.Size()) {
+		fmt.Fprintf(os.Stderr, "warning: %v was truncated, parsing it from the beginning\n", f.Name())
+		*st = *newFollowState(st.parser, st.source)
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+	}
+	n, err := io.Copy(st.stream, f)
+	return n != 0, err
+}
+
+// step returns the crashes that appeared in the log since the last reported one
+// and are ready to be reported. A crash is ready once the log stopped growing, or
+// followSettleTime passed since it was noticed, or flush is set.
+func (st *followState) step(now time.Time, grown, flush bool) []*logparser.Report {
+	if st.crashSeen.IsZero() {
+		if !st.stream.ContainsCrash() {
+			return nil
+		}
+		st.crashSeen = now
+	}
+	if !flush && (grown || now.Sub(st.crashSeen) < followPollPeriod) && now.Sub(st.crashSeen) < followSettleTime {
+		return nil
+	}
+	st.crashSeen = time.Time{}
+	// A crash that was detected, but could not be parsed, is not looked at again.
+	return st.stream.Parse()
+}
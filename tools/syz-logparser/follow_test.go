@@ -0,0 +1,61 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowStep(t *testing.T) {
+	parser := newTestParser(t)
+	st := newFollowState(parser.parser, "log")
+	now := time.Now()
+	const (
+		boot  = "[    0.000000] booting\n"
+		crash = "[   10.000000] ------------[ cut here ]------------\n" +
+			"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+			"[   10.000000] Call Trace:\n" +
+			"[   10.000000]  bar+0x1/0x2\n" +
+			"[   10.000000]  baz+0x1/0x2\n"
+	)
+	titles := func(crashes []*logparser.Report) []string {
+		var res []string
+		for _, crash := range crashes {
+			res = append(res, crash.Title)
+		}
+		return res
+	}
+
+	st.stream.Write([]byte(boot))
+	assert.Empty(t, st.step(now, true, false))
+	// The crash is not reported until its report settles.
+	st.stream.Write([]byte(crash))
+	assert.Empty(t, st.step(now, true, false))
+	assert.Empty(t, st.step(now.Add(followPollPeriod/2), false, false))
+	assert.Empty(t, st.step(now.Add(followPollPeriod), true, false))
+	crashes := st.step(now.Add(followPollPeriod), false, false)
+	assert.Equal(t, []string{"WARNING in bar"}, titles(crashes))
+	assert.Equal(t, len(boot)+len("[   10.000000] ------------[ cut here ]------------\n"), crashes[0].StartPos)
+	assert.Equal(t, 0, crashes[0].OriginalIndex)
+	// The reported crash is not reported again.
+	assert.Empty(t, st.step(now.Add(followSettleTime), false, true))
+
+	// A log that keeps growing is parsed after followSettleTime.
+	now = now.Add(time.Hour)
+	st.stream.Write([]byte(crash))
+	assert.Empty(t, st.step(now, true, false))
+	assert.Empty(t, st.step(now.Add(followSettleTime/2), true, false))
+	crashes = st.step(now.Add(followSettleTime), true, false)
+	assert.Equal(t, []string{"WARNING in bar"}, titles(crashes))
+	// Crashes are numbered across steps.
+	assert.Equal(t, 1, crashes[0].OriginalIndex)
+
+	// Flush reports pending crashes immediately.
+	st.stream.Write([]byte(crash))
+	assert.Equal(t, []string{"WARNING in bar"}, titles(st.step(now, true, true)))
+}
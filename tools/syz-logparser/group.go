@@ -0,0 +1,104 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+// noFrame is the -group-by=frame key of crashes without a frame.
+const noFrame = "(no frame)"
+
+// groupKeys maps -group-by values to the crash field crashes are grouped by.
+var groupKeys = map[string]func(rep *logparser.Report) string{
+	"title":            func(rep *logparser.Report) string { return rep.Title },
+	"normalized_title": func(rep *logparser.Report) string { return rep.NormalizedTitle },
+	"type":             func(rep *logparser.Report) string { return rep.Type },
+	"frame": func(rep *logparser.Report) string {
+		if rep.Frame == "" {
+			return noFrame
+		}
+		return rep.Frame
+	},
+}
+
+func checkGroupKey(key string) error {
+	if key == "" || groupKeys[key] != nil {
+		return nil
+	}
+	var known []string
+	for name := range groupKeys {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return fmt.Errorf("unknown -group-by key %q (valid keys: %v)", key, strings.Join(known, ", "))
+}
+
+// crashGroup is a set of crashes with the same -group-by key.
+type crashGroup struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+	// Title is the title of the first crash of the group.
+	Title string `json:"title"`
+}
+
+// groupCrashes groups crashes of all logs by the -group-by key.
+// Groups are sorted by decreasing count, then by key.
+func groupCrashes(logs []*parsedLog, key string) []*crashGroup {
+	field := groupKeys[key]
+	groups := []*crashGroup{}
+	byKey := make(map[string]*crashGroup)
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			k := field(rep)
+			group := byKey[k]
+			if group == nil {
+				group = &crashGroup{Key: k, Title: rep.Title}
+				byKey[k] = group
+				groups = append(groups, group)
+			}
+			group.Count += max(rep.Count, 1)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+func emitGroups(w io.Writer, groups []*crashGroup, format string) {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		indentJSON(enc)
+		if err := enc.Encode(groups); err != nil {
+			tool.Fail(err)
+		}
+	case formatJSONL:
+		enc := json.NewEncoder(w)
+		for _, group := range groups {
+			if err := enc.Encode(group); err != nil {
+				tool.Fail(err)
+			}
+		}
+	default:
+		for _, group := range groups {
+			if group.Key == group.Title {
+				fmt.Fprintf(w, "%6d  %v\n", group.Count, group.Key)
+			} else {
+				fmt.Fprintf(w, "%6d  %v  (%v)\n", group.Count, group.Key, group.Title)
+			}
+		}
+	}
+}
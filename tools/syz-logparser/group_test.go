@@ -0,0 +1,52 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupCrashes(t *testing.T) {
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Type: "WARNING", Frame: "foo"},
+			{Title: "KASAN: use-after-free Read in bar", Type: "KASAN-USE-AFTER-FREE-READ", Frame: "bar"},
+		}},
+		{crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Type: "WARNING", Frame: "foo", Count: 2},
+			{Title: "WARNING: refcount bug", Type: "REFCOUNT_WARNING"},
+			{Title: "lost connection to test machine", Type: "LOST_CONNECTION"},
+		}},
+	}
+	assert.Equal(t, []*crashGroup{
+		{Key: "foo", Count: 3, Title: "WARNING in foo"},
+		{Key: noFrame, Count: 2, Title: "WARNING: refcount bug"},
+		{Key: "bar", Count: 1, Title: "KASAN: use-after-free Read in bar"},
+	}, groupCrashes(logs, "frame"))
+	assert.Equal(t, []*crashGroup{
+		{Key: "WARNING", Count: 3, Title: "WARNING in foo"},
+		{Key: "KASAN-USE-AFTER-FREE-READ", Count: 1, Title: "KASAN: use-after-free Read in bar"},
+		{Key: "LOST_CONNECTION", Count: 1, Title: "lost connection to test machine"},
+		{Key: "REFCOUNT_WARNING", Count: 1, Title: "WARNING: refcount bug"},
+	}, groupCrashes(logs, "type"))
+	assert.Equal(t, []*crashGroup{
+		{Key: "kernel BUG at fs/foo.c!", Count: 2, Title: "kernel BUG at fs/foo.c:10!"},
+	}, groupCrashes([]*parsedLog{{crashes: []*logparser.Report{
+		{Title: "kernel BUG at fs/foo.c:10!", NormalizedTitle: "kernel BUG at fs/foo.c!"},
+		{Title: "kernel BUG at fs/foo.c:12!", NormalizedTitle: "kernel BUG at fs/foo.c!"},
+	}}}, "normalized_title"))
+	assert.Empty(t, groupCrashes(nil, "title"))
+
+	buf := new(bytes.Buffer)
+	emitGroups(buf, groupCrashes(logs, "title")[:2], formatHuman)
+	assert.Equal(t, "     3  WARNING in foo\n     1  KASAN: use-after-free Read in bar\n", buf.String())
+
+	assert.NoError(t, checkGroupKey("frame"))
+	assert.EqualError(t, checkGroupKey("file"),
+		`unknown -group-by key "file" (valid keys: frame, normalized_title, title, type)`)
+}
@@ -0,0 +1,333 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// inputPaths returns the list of logs to parse: the command line arguments
+// followed by the paths listed in -files-from and the files matching -glob.
+// Stdin ("-") is used if there are none.
+func inputPaths() ([]string, error) {
+	paths := flag.Args()
+	if *flagFilesFrom != "" {
+		listed, err := readManifest(*flagFilesFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read -files-from manifest: %w", err)
+		}
+		paths = append(paths, listed...)
+	}
+	if *flagGlob != "" {
+		matches, err := expandGlob(*flagGlob)
+		if err != nil {
+			return nil, fmt.Errorf("bad -glob pattern %q: %w", *flagGlob, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match -glob pattern %q", *flagGlob)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	return paths, nil
+}
+
+// readManifest returns the paths listed in the file (or stdin if it's "-"), one per line.
+// Empty lines and lines starting with # are skipped.
+func readManifest(manifest string) ([]string, error) {
+	var data []byte
+	var err error
+	if manifest == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(manifest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, nil
+}
+
+// inputName returns the name of the input path for messages.
+func inputName(path string) string {
+	if *flagBase64 {
+		// Don't dump the whole encoded log.
+		return "-base64"
+	}
+	return path
+}
+
+// dryRun reads all inputs without parsing them and prints read errors to errOut and
+// the number of readable inputs to w. It returns the exit code.
+func dryRun(w, errOut io.Writer, paths []string, target string) int {
+	readable := 0
+	for _, path := range paths {
+		_, release, err := readLog(path)
+		if err != nil {
+			fmt.Fprintf(errOut, "%v: failed to read log file: %v\n", inputName(path), err)
+			continue
+		}
+		release()
+		readable++
+	}
+	fmt.Fprintf(w, "target %v: %v of %v files are parseable\n", target, readable, len(paths))
+	if readable != len(paths) {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// expandGlob returns regular files matching the shell-style pattern.
+// In addition to the filepath.Match syntax, a "**" path element matches
+// any number of nested directories, e.g. "logs/**/*.log" or "logs/2024-*/**/*.log".
+func expandGlob(pattern string) ([]string, error) {
+	root, rest, recursive := strings.Cut(pattern, "**")
+	if !recursive {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+		return files, nil
+	}
+	sep := string(filepath.Separator)
+	rest = strings.TrimPrefix(rest, sep)
+	if rest == "" {
+		rest = "*"
+	}
+	if _, err := filepath.Match(rest, ""); err != nil {
+		return nil, err
+	}
+	if root == "" {
+		root = "."
+	}
+	// The directories before "**" can be patterns too, a literal one is walked as is
+	// (so that a missing directory is an error).
+	roots := []string{root}
+	if strings.ContainsAny(root, `*?[`) {
+		matches, err := filepath.Glob(strings.TrimSuffix(root, sep))
+		if err != nil {
+			return nil, err
+		}
+		roots = nil
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				roots = append(roots, match)
+			}
+		}
+	}
+	var files []string
+	for _, root := range roots {
+		if err := walkGlob(root, rest, &files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// walkGlob appends the regular files under root whose trailing path elements match rest to files.
+func walkGlob(root, rest string, files *[]string) error {
+	sep := string(filepath.Separator)
+	elems := strings.Count(rest, sep) + 1
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(rel, sep)
+		if len(parts) < elems {
+			return nil
+		}
+		if ok, _ := filepath.Match(rest, strings.Join(parts[len(parts)-elems:], sep)); ok {
+			*files = append(*files, path)
+		}
+		return nil
+	})
+}
+
+// readLog reads the log from the given file, from stdin if the path is empty or "-",
+// or fetches it if the path is an http(s) URL. With -mmap local files are mapped
+// into memory instead of being read. The returned release function must be called
+// once the data (and anything that references it) is no longer used.
+// With -base64 the path is the base64-encoded log itself.
+// Compressed logs are transparently decompressed.
+func readLog(path string) ([]byte, func(), error) {
+	var data []byte
+	var err error
+	release := func() {}
+	if *flagBase64 {
+		data, err = decodeBase64Log(path)
+		// The data has no file name to detect compression by.
+		path = ""
+	} else if path == "" || path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else if isURL(path) {
+		data, err = fetchLog(path)
+	} else if *flagMmap {
+		data, release, err = mapFile(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if isGzip(path, data) || isZstd(path, data) {
+		// The decompressed data lives in the heap, the compressed one is not needed anymore.
+		decompressed, err := decompress(path, data)
+		release()
+		if err != nil {
+			return nil, nil, err
+		}
+		data, release = decompressed, func() {}
+	}
+	if !*flagForce {
+		if err := checkText(data); err != nil {
+			release()
+			return nil, nil, err
+		}
+	}
+	return data, release, nil
+}
+
+// decodeBase64Log decodes the log passed with -base64. Trailing whitespace (e.g. a newline
+// of a shell variable) is ignored.
+func decodeBase64Log(encoded string) ([]byte, error) {
+	encoded = strings.TrimRightFunc(encoded, unicode.IsSpace)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	var corrupt base64.CorruptInputError
+	if errors.As(err, &corrupt) {
+		pos := int(corrupt)
+		start, end := max(pos-10, 0), min(pos+10, len(encoded))
+		return nil, fmt.Errorf("invalid base64 data at byte %d (near %q)", pos, encoded[start:end])
+	}
+	return data, err
+}
+
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+func fetchLog(url string) ([]byte, error) {
+	client := &http.Client{Timeout: *flagTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %v", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+func isGzip(path string, data []byte) bool {
+	return strings.HasSuffix(path, ".gz") || bytes.HasPrefix(data, gzipMagic)
+}
+
+func isZstd(path string, data []byte) bool {
+	return strings.HasSuffix(path, ".zst") || bytes.HasPrefix(data, zstdMagic)
+}
+
+// decompress unpacks gzip or zstd data detected either by the .gz/.zst extension
+// or by the magic bytes. Other data is returned as is.
+func decompress(path string, data []byte) ([]byte, error) {
+	if isZstd(path, data) {
+		return decompressZstd(data)
+	}
+	if !isGzip(path, data) {
+		return data, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip log: %w", err)
+	}
+	defer r.Close()
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip log: %w", err)
+	}
+	return data, nil
+}
+
+func decompressZstd(data []byte) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress zstd log: %w", err)
+	}
+	defer r.Close()
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress zstd log: %w", err)
+	}
+	return data, nil
+}
+
+const (
+	// textCheckSize is the size of the log prefix examined by checkText.
+	textCheckSize = 64 << 10
+	// minTextCheckSize is the size of the shortest log examined by checkText,
+	// a few bytes are not enough to tell.
+	minTextCheckSize = 256
+	// maxBinaryRatio is the maximum share of non-printable bytes in a text log.
+	// Console logs may contain some garbage (e.g. from a serial port during reboot).
+	maxBinaryRatio = 0.3
+)
+
+// checkText fails if the start of data has too many bytes that are neither printable
+// characters (including valid UTF-8) nor whitespace, i.e. the input is likely not a log.
+func checkText(data []byte) error {
+	data = data[:min(len(data), textCheckSize)]
+	binary := 0
+	for pos := 0; pos < len(data); {
+		r, size := utf8.DecodeRune(data[pos:])
+		if r == utf8.RuneError && size == 1 && len(data)-pos >= utf8.UTFMax ||
+			r < ' ' && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != '\b' && r != '\x1b' ||
+			r == 0x7f {
+			binary += size
+		}
+		pos += size
+	}
+	if len(data) >= minTextCheckSize && float64(binary) > maxBinaryRatio*float64(len(data)) {
+		return fmt.Errorf("input does not look like a text log (%v%% of the first %v bytes are not printable),"+
+			" use -force to parse it anyway", binary*100/len(data), len(data))
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompress(t *testing.T) {
+	const log = "BUG: unable to handle kernel paging request\n"
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(log))
+	w.Close()
+	gz := buf.Bytes()
+
+	for _, path := range []string{"log.gz", "log", "-"} {
+		data, err := decompress(path, gz)
+		assert.NoError(t, err, path)
+		assert.Equal(t, log, string(data), path)
+	}
+	data, err := decompress("log.txt", []byte(log))
+	assert.NoError(t, err)
+	assert.Equal(t, log, string(data))
+
+	_, err = decompress("log.gz", []byte(log))
+	assert.Error(t, err)
+}
+
+func TestDecompressZstd(t *testing.T) {
+	const log = "BUG: unable to handle kernel paging request\n"
+	buf := new(bytes.Buffer)
+	w, err := zstd.NewWriter(buf)
+	assert.NoError(t, err)
+	w.Write([]byte(log))
+	w.Close()
+	zst := buf.Bytes()
+
+	for _, path := range []string{"log.zst", "log", "-"} {
+		data, err := decompress(path, zst)
+		assert.NoError(t, err, path)
+		assert.Equal(t, log, string(data), path)
+	}
+	_, err = decompress("log.zst", []byte(log))
+	assert.ErrorContains(t, err, "failed to decompress zstd log")
+	// Valid magic followed by garbage.
+	_, err = decompress("log", append(append([]byte{}, zstdMagic...), "garbage"...))
+	assert.ErrorContains(t, err, "failed to decompress zstd log")
+}
+
+func TestReadLogMmap(t *testing.T) {
+	*flagMmap = true
+	defer func() { *flagMmap = false }()
+	const log = "BUG: unable to handle kernel paging request\n"
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "log")
+	assert.NoError(t, os.WriteFile(plain, []byte(log), 0644))
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(log))
+	w.Close()
+	compressed := filepath.Join(dir, "log.gz")
+	assert.NoError(t, os.WriteFile(compressed, buf.Bytes(), 0644))
+	empty := filepath.Join(dir, "empty")
+	assert.NoError(t, os.WriteFile(empty, nil, 0644))
+
+	for path, want := range map[string]string{plain: log, compressed: log, empty: ""} {
+		data, release, err := readLog(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, want, string(data), path)
+		release()
+	}
+	_, _, err := readLog(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestReadLogStdin(t *testing.T) {
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n" +
+		"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+	compressed := new(bytes.Buffer)
+	w := gzip.NewWriter(compressed)
+	w.Write([]byte(log))
+	w.Close()
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	// feed replaces stdin with a pipe that returns data.
+	feed := func(data []byte) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			w.Write(data)
+			w.Close()
+		}()
+		os.Stdin = r
+	}
+	for _, path := range []string{"-", ""} {
+		for _, data := range [][]byte{[]byte(log), compressed.Bytes()} {
+			feed(data)
+			got, release, err := readLog(path)
+			assert.NoError(t, err, path)
+			assert.Equal(t, log, string(got), path)
+			release()
+			os.Stdin.Close()
+		}
+	}
+	feed([]byte(log))
+	parsed, err := newTestParser(t).parseLog("-")
+	assert.NoError(t, err)
+	os.Stdin.Close()
+	assert.Empty(t, parsed.source)
+	assert.Len(t, parsed.crashes, 1)
+	assert.Equal(t, "WARNING in bar", parsed.crashes[0].Title)
+}
+
+func TestCheckText(t *testing.T) {
+	texts := []string{
+		"",
+		"\x1f\x00",
+		"[   10.000000] BUG: KASAN: use-after-free in foo+0x1/0x2\r\n",
+		"\x1b[0;32m  OK  \x1b[0m Started Journal Service.\n\tCPU: 0 — ünïcödé\n",
+		// Some serial port garbage in a real log.
+		"\x00\xff\xfe" + strings.Repeat("[   10.000000] booting\n", 2),
+		strings.Repeat("x", textCheckSize) + strings.Repeat("\x00", 100),
+	}
+	for _, text := range texts {
+		assert.NoError(t, checkText([]byte(text)), "%q", text)
+	}
+	elf := append([]byte("\x7fELF\x02\x01\x01\x00"), make([]byte, 992)...)
+	assert.EqualError(t, checkText(elf), "input does not look like a text log"+
+		" (99% of the first 1000 bytes are not printable), use -force to parse it anyway")
+	assert.Error(t, checkText([]byte(strings.Repeat("\xff\xfe\x00BUG\n", 100))))
+
+	file := filepath.Join(t.TempDir(), "vmlinux")
+	assert.NoError(t, os.WriteFile(file, elf, 0644))
+	_, _, err := readLog(file)
+	assert.ErrorContains(t, err, "input does not look like a text log")
+	*flagForce = true
+	defer func() { *flagForce = false }()
+	data, _, err := readLog(file)
+	assert.NoError(t, err)
+	assert.Equal(t, elf, data)
+}
+
+func TestDecodeBase64Log(t *testing.T) {
+	const log = "BUG: unable to handle kernel paging request\n"
+	encoded := base64.StdEncoding.EncodeToString([]byte(log))
+	data, err := decodeBase64Log(encoded + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, log, string(data))
+
+	_, err = decodeBase64Log(encoded[:12] + "!" + encoded[13:])
+	assert.EqualError(t, err, fmt.Sprintf("invalid base64 data at byte 12 (near %q)", encoded[2:12]+"!"+encoded[13:22]))
+	_, err = decodeBase64Log(encoded[:len(encoded)-1])
+	assert.ErrorContains(t, err, "invalid base64 data at byte")
+}
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "log")
+	assert.NoError(t, os.WriteFile(good, []byte("BUG: unable to handle kernel paging request\n"), 0644))
+	bad := filepath.Join(dir, "log.gz")
+	assert.NoError(t, os.WriteFile(bad, []byte("not gzip"), 0644))
+	missing := filepath.Join(dir, "missing")
+
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	assert.Equal(t, exitOK, dryRun(out, errOut, []string{good}, "linux/amd64"))
+	assert.Equal(t, "target linux/amd64: 1 of 1 files are parseable\n", out.String())
+	assert.Empty(t, errOut.String())
+
+	out.Reset()
+	assert.Equal(t, exitFailure, dryRun(out, errOut, []string{good, bad, missing}, "linux/amd64"))
+	assert.Equal(t, "target linux/amd64: 1 of 3 files are parseable\n", out.String())
+	assert.Contains(t, errOut.String(), bad+": failed to read log file: failed to decompress gzip log")
+	assert.Contains(t, errOut.String(), missing+": failed to read log file: ")
+}
+
+func TestReadManifest(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "manifest")
+	assert.NoError(t, os.WriteFile(manifest, []byte("# campaign logs\r\n"+
+		"a.log\r\n"+
+		"\n"+
+		"  dir/b.log  \n"+
+		"  # disabled.log\n"+
+		"https://example.com/c.log"), 0644))
+	paths, err := readManifest(manifest)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.log", "dir/b.log", "https://example.com/c.log"}, paths)
+
+	_, err = readManifest(manifest + ".missing")
+	assert.Error(t, err)
+}
+
+func TestExpandGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"a.log", "b.txt", "sub/c.log", "sub/deep/d.log",
+		"2023-12/w.log", "2024-01/x.log", "2024-01/n/y.log", "2024-02/z.txt", "2024-03",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.log", []string{"a.log"}},
+		{"*", []string{"2024-03", "a.log", "b.txt"}},
+		{"sub/*.log", []string{"sub/c.log"}},
+		{"**/*.log", []string{"2023-12/w.log", "2024-01/n/y.log", "2024-01/x.log", "a.log",
+			"sub/c.log", "sub/deep/d.log"}},
+		{"sub/**", []string{"sub/c.log", "sub/deep/d.log"}},
+		{"**/deep/*.log", []string{"sub/deep/d.log"}},
+		// Patterns before "**" select the directories to walk (2024-03 is a file).
+		{"2024-*/**/*.log", []string{"2024-01/n/y.log", "2024-01/x.log"}},
+		{"2024-0[23]/**", []string{"2024-02/z.txt"}},
+		{"20??-*/**/w.log", []string{"2023-12/w.log"}},
+		{"2025-*/**/*.log", nil},
+	}
+	for _, test := range tests {
+		files, err := expandGlob(filepath.Join(dir, filepath.FromSlash(test.pattern)))
+		assert.NoError(t, err, test.pattern)
+		var got []string
+		for _, file := range files {
+			rel, err := filepath.Rel(dir, file)
+			assert.NoError(t, err)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		assert.Equal(t, test.want, got, test.pattern)
+	}
+	for _, pattern := range []string{"[", "**/[", "[/**/*.log"} {
+		_, err := expandGlob(filepath.Join(dir, pattern))
+		assert.Error(t, err, pattern)
+	}
+	_, err := expandGlob(filepath.Join(dir, "missing", "**", "*.log"))
+	assert.Error(t, err)
+}
+
+func TestFetchLog(t *testing.T) {
+	const log = "BUG: unable to handle kernel paging request\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/console.log":
+			fmt.Fprint(w, log)
+		case "/slow.log":
+			// Block until the client gives up.
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	timeout := *flagTimeout
+	*flagTimeout = 100 * time.Millisecond
+	defer func() { *flagTimeout = timeout }()
+
+	data, err := fetchLog(srv.URL + "/console.log")
+	assert.NoError(t, err)
+	assert.Equal(t, log, string(data))
+	_, err = fetchLog(srv.URL + "/missing.log")
+	assert.EqualError(t, err, "unexpected HTTP status: 404 Not Found")
+	_, err = fetchLog(srv.URL + "/slow.log")
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+}
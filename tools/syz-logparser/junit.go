@@ -0,0 +1,84 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// emitJUnit writes a JUnit XML test suite per log, each crash being a failed test case
+// (or a skipped one if the crash is suppressed).
+func emitJUnit(w io.Writer, logs []*parsedLog) {
+	out := &junitTestSuites{}
+	for _, parsed := range logs {
+		suite := junitTestSuite{Name: parsed.source}
+		if suite.Name == "" {
+			suite.Name = "stdin"
+		}
+		for _, rep := range parsed.crashes {
+			tc := junitTestCase{
+				Name:      rep.Title,
+				ClassName: rep.Type,
+			}
+			if rep.Suppressed {
+				tc.Skipped = &junitSkipped{Message: "suppressed"}
+				suite.Skipped++
+			} else {
+				tc.Failure = &junitFailure{
+					Message: rep.Title,
+					Type:    rep.Type,
+					Body:    rep.Report,
+				}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+		}
+		out.Suites = append(out.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		tool.Fail(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		tool.Fail(err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		tool.Fail(err)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitJUnit(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{
+			{Title: "KASAN: use-after-free Read in foo", Type: "KASAN-READ", Report: "BUG: KASAN: <foo>\n"},
+			{Title: "lost connection to test machine", Type: "LOST_CONNECTION", Suppressed: true},
+		}},
+		{},
+	}
+	buf := new(bytes.Buffer)
+	emitJUnit(buf, logs)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="a.log" tests="2" failures="1" skipped="1">
+    <testcase name="KASAN: use-after-free Read in foo" classname="KASAN-READ">
+      <failure message="KASAN: use-after-free Read in foo" type="KASAN-READ"><![CDATA[BUG: KASAN: <foo>
+]]></failure>
+    </testcase>
+    <testcase name="lost connection to test machine" classname="LOST_CONNECTION">
+      <skipped message="suppressed"></skipped>
+    </testcase>
+  </testsuite>
+  <testsuite name="stdin" tests="0" failures="0" skipped="0"></testsuite>
+</testsuites>
+`, buf.String())
+}
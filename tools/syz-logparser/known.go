@@ -0,0 +1,77 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// loadKnownFingerprints reads the fingerprints of known crashes for -only-new.
+// The file is the tool's own JSON output (-json, -json-envelope or -jsonl),
+// or a JSON array of fingerprint strings.
+func loadKnownFingerprints(file string) (map[string]bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known crashes: %w", err)
+	}
+	known := make(map[string]bool)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for idx := 1; ; idx++ {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%v: bad known crashes JSON: %w", file, err)
+		}
+		if err := addKnownFingerprints(known, value); err != nil {
+			return nil, fmt.Errorf("%v: value #%v: %w", file, idx, err)
+		}
+	}
+	return known, nil
+}
+
+// addKnownFingerprints adds the fingerprints from a crash object, an envelope object,
+// or an array of any of these or of fingerprint strings.
+func addKnownFingerprints(known map[string]bool, value json.RawMessage) error {
+	var list []json.RawMessage
+	if json.Unmarshal(value, &list) == nil {
+		for _, elem := range list {
+			var fingerprint string
+			if json.Unmarshal(elem, &fingerprint) == nil {
+				known[fingerprint] = true
+				continue
+			}
+			if err := addKnownFingerprints(known, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var obj struct {
+		Fingerprint string            `json:"fingerprint"`
+		Crashes     []json.RawMessage `json:"crashes"`
+	}
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return fmt.Errorf("expected a crash, an envelope or an array: %w", err)
+	}
+	if obj.Crashes != nil {
+		for _, crash := range obj.Crashes {
+			if err := addKnownFingerprints(known, crash); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if obj.Fingerprint == "" {
+		return fmt.Errorf("crash has no fingerprint")
+	}
+	known[obj.Fingerprint] = true
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKnownFingerprints(t *testing.T) {
+	logs := []*parsedLog{{crashes: []*logparser.Report{
+		{Title: "WARNING in foo", Fingerprint: "aa"},
+		{Title: "WARNING in bar", Fingerprint: "bb"},
+	}}}
+	want := map[string]bool{"aa": true, "bb": true}
+	dir := t.TempDir()
+	load := func(data []byte) (map[string]bool, error) {
+		file := filepath.Join(dir, "known.json")
+		assert.NoError(t, os.WriteFile(file, data, 0644))
+		return loadKnownFingerprints(file)
+	}
+	// The tool's own output round-trips in all JSON formats.
+	for _, format := range []string{formatJSON, formatJSONL, "envelope"} {
+		buf := new(bytes.Buffer)
+		switch format {
+		case formatJSON:
+			emitJSON(buf, logs)
+		case formatJSONL:
+			emitJSONL(buf, logs)
+		default:
+			emitJSONEnvelope(buf, logs, &emitOptions{format: formatJSON})
+		}
+		known, err := load(buf.Bytes())
+		assert.NoError(t, err, format)
+		assert.Equal(t, want, known, format)
+	}
+	known, err := load([]byte(`["aa", "bb"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, want, known)
+	known, err = load(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, known)
+
+	_, err = load([]byte(`[{"title": "WARNING in foo"}]`))
+	assert.ErrorContains(t, err, "value #1: crash has no fingerprint")
+	_, err = load([]byte(`{"fingerprint": "aa"}` + "\n" + `42`))
+	assert.ErrorContains(t, err, "value #2: expected a crash, an envelope or an array")
+	_, err = load([]byte(`[{`))
+	assert.ErrorContains(t, err, "bad known crashes JSON")
+	_, err = loadKnownFingerprints(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read known crashes")
+}
+
+func TestOnlyNewFilter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`["aa"]`), 0644))
+	*flagOnlyNew = file
+	defer func() { *flagOnlyNew = "" }()
+	filters, err := buildFilters()
+	assert.NoError(t, err)
+	crashes := []*logparser.Report{
+		{Title: "WARNING in foo", Fingerprint: "aa"},
+		{Title: "WARNING in bar", Fingerprint: "bb"},
+	}
+	removed := make(map[string]int)
+	assert.Equal(t, crashes[1:], filterCrashes(crashes, filters, removed))
+	assert.Equal(t, map[string]int{"only-new": 1}, removed)
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// printTargets prints all supported targets as OS/arch pairs, one per line.
+func printTargets(w io.Writer) {
+	var list []string
+	for os, arches := range targets.List {
+		for arch := range arches {
+			list = append(list, os+"/"+arch)
+		}
+	}
+	sort.Strings(list)
+	for _, target := range list {
+		fmt.Fprintln(w, target)
+	}
+}
+
+// printTypes prints the names of all crash report types, one per line.
+func printTypes(w io.Writer) {
+	for _, typ := range crash.AllTypes {
+		fmt.Fprintln(w, typ.String())
+	}
+}
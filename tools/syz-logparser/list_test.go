@@ -0,0 +1,33 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintTargets(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printTargets(buf)
+	list := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Contains(t, list, "linux/amd64")
+	assert.Contains(t, list, "freebsd/amd64")
+	assert.True(t, sort.StringsAreSorted(list))
+}
+
+func TestPrintTypes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printTypes(buf)
+	list := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Contains(t, list, "KASAN-READ")
+	assert.Contains(t, list, "UNKNOWN")
+	// Every printed type must be accepted by -type.
+	_, err := parseTypeList(strings.Join(list, ","))
+	assert.NoError(t, err)
+}
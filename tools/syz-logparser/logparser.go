@@ -4,181 +4,821 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
-	"strings"
+	"time"
 
-	"github.com/google/syzkaller/pkg/config"
-	"github.com/google/syzkaller/pkg/mgrconfig"
-	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/tool"
-	_ "github.com/google/syzkaller/sys" // register targets
 	"github.com/google/syzkaller/sys/targets"
 )
 
 var (
-	flagOS     = flag.String("os", targets.Linux, "target OS of the log")
-	flagArch   = flag.String("arch", runtime.GOARCH, "target architecture of the log")
+	flagOS         = flag.String("os", targets.Linux, "target OS of the log")
+	flagArch       = flag.String("arch", runtime.GOARCH, "target architecture of the log")
+	flagAutoTarget = flag.Bool("auto-target", false,
+		"guess the target of every log from its boot banner and register dumps, fall back to -os/-arch if that fails")
+	flagVMArch = flag.String("vmarch", "",
+		"architecture of the kernel that produced the log if it differs from -arch (default: -arch)")
+	flagStrictTarget = flag.Bool("strict-target", false,
+		"fail if -os/-arch/-vmarch conflict with the target of -config instead of warning")
+	flagLogFormat = flag.String("log-format", logparser.LogFormatKernel,
+		"format of the logs: kernel, android (converts adb logcat, /dev/kmsg and <N> priority line prefixes)")
+	flagReporterPreset = flag.String("reporter-preset", logparser.ReporterPresetDefault,
+		"reporter strictness: default, strict (drop corrupted reports), "+
+			"lenient (ignore the ignores and interests of -config)")
 	flagConfig = flag.String("config", "", "optional manager config to reuse parsing settings")
-	flagJSON   = flag.Bool("json", false, "emit parsed crashes as JSON")
-	flagAll    = flag.Bool("all", false, "parse all crash reports (default: only the first)")
+	flagDryRun = flag.Bool("dry-run", false,
+		"check that the target resolves and all inputs are readable, print a summary and exit without parsing")
+	flagCheckConfig = flag.Bool("check-config", false, "validate the -config file and exit without parsing any logs")
+	flagJSON        = flag.Bool("json", false, "emit parsed crashes as JSON (same as -format=json)")
+	flagJSONCompact = flag.Bool("json-compact", false,
+		"write JSON (and SARIF) output without indentation, as a single line")
+	flagIndent = flag.Int("indent", 2, "number of spaces to indent JSON (and SARIF) output by, 0 means compact")
+	flagJSONL  = flag.Bool("jsonl", false,
+		"emit parsed crashes as newline-delimited JSON, one object per line (same as -format=jsonl)")
+	flagJSONEnvelope = flag.Bool("json-envelope", false,
+		"emit JSON as an object with run metadata and a crashes array (implies -json)")
+	flagAll   = flag.Bool("all", false, "parse all crash reports (default: only the first)")
+	flagType  = flag.String("type", "", "comma-separated list of report types to keep (e.g. KASAN-READ,LOCKDEP)")
+	flagClass = flag.String("class", "",
+		"comma-separated list of crash classes to keep: hung_task, rcu_stall, soft_lockup, oom, panic")
+	flagMinSeverity = flag.String("min-severity", "",
+		"keep only crashes of at least the given severity: warn, error, fatal")
+	flagTitleRegexp = flag.String("title-regexp", "", "keep only crashes with a title or alt title matching the regexp")
+	flagFrameRegexp = flag.String("frame-regexp", "", "keep only crashes with a frame matching the regexp")
+	flagConfigDir   = flag.String("config-dir", "",
+		"directory with manager configs (*.cfg, *.json), the one with the target of the log is used")
+	flagSuppressions = flag.String("suppressions", "", "file with additional suppression regexps, one per line")
+	flagSince        = flag.Float64("since", 0,
+		"keep only crashes whose first line has a console timestamp of at least N seconds")
+	flagUntil = flag.Float64("until", 0,
+		"keep only crashes whose first line has a console timestamp of at most N seconds")
+	flagRequireTimestamp = flag.Bool("require-timestamp", false, "drop crashes whose first line has no console timestamp")
+	flagIgnore           = regexpList("ignore", "drop crashes whose raw log range matches the regexp (can be repeated)")
+	flagOnlyNew          = flag.String("only-new", "",
+		"drop crashes whose fingerprint is in the file (JSON output of the tool or an array of fingerprints)")
+	flagExcludeSuppressed = flag.Bool("exclude-suppressed", false, "drop suppressed crashes from output")
+	flagExcludeCorrupted  = flag.Bool("exclude-corrupted", false, "drop corrupted crashes from output")
+	flagReportOnly        = flag.Bool("report-only", false,
+		"print only the raw report bodies separated by -report-delimiter lines")
+	flagReportDelimiter = flag.String("report-delimiter", "---", "line printed between report bodies with -report-only")
+	flagStats           = flag.Bool("stats", false, "print crash counts grouped by type and title instead of the crashes")
+	flagDedup           = flag.Bool("dedup", false, "collapse crashes with the same title and frame within a log")
+	flagMergeAltTitles  = flag.Bool("merge-alt-titles", false,
+		"with -dedup, collapse crashes that share the title or any alt title (the shortest title is kept)")
+	flagDedupAcrossFiles = flag.Bool("dedup-across-files", false,
+		"collapse crashes with the same fingerprint across all input logs")
+	flagVmlinux = flag.String("vmlinux", "",
+		"path to vmlinux to symbolize reports (implies -kernel-obj=dir of vmlinux)")
+	flagKernelObj   = flag.String("kernel-obj", "", "path to kernel build/obj dir to symbolize reports")
+	flagKernelSrc   = flag.String("kernel-src", "", "path to kernel sources (defaults to -kernel-obj)")
+	flagMaintainers = flag.Bool("maintainers", false,
+		"extract guilty file and maintainers of each crash (requires -kernel-src or -kernel-obj)")
+	flagCount      = flag.Bool("count", false, "print only the number of crashes")
+	flagTitles     = flag.Bool("titles", false, "print only crash titles, one per line")
+	flagTimeout    = flag.Duration("timeout", time.Minute, "timeout for fetching logs from http(s) URLs")
+	flagContext    = flag.Int("context", 0, "include up to N raw log lines before and after each crash")
+	flagRawRange   = flag.Bool("raw-range", false, "also emit the raw log bytes of the crash range")
+	flagPerBoot    = flag.Bool("per-boot", false, "split logs into per-boot segments and parse each one separately")
+	flagBootRegexp = flag.String("boot-regexp", logparser.DefaultBootRegexp,
+		"regexp matching the first line of each boot for -per-boot")
+	flagGroupBy = flag.String("group-by", "",
+		"instead of the crashes, print crash counts grouped by title, normalized_title, type or frame")
+	flagReverse = flag.Bool("reverse", false,
+		"output crashes in reverse order, the last one first (applied before -offset and -limit)")
+	flagSort = flag.String("sort", "",
+		"sort crashes of every log by pos, title or type (ties are ordered by pos)")
+	flagLimit  = flag.Int("limit", 0, "emit at most N crashes (0 means unlimited)")
+	flagOffset = flag.Int("offset", 0,
+		"skip the first N crashes after filtering (use with -limit for pagination)")
+	flagFingerprintFields = flag.String("fingerprint-fields", "title,frame",
+		"comma-separated crash fields used to compute fingerprints (title, frame, type)")
+	flagNth   = flag.Int("nth", 0, "parse only the N-th crash report (1-based, ignored with -all)")
+	flagColor = flag.String("color", "auto",
+		"colorize human-readable output: auto (if writing to a terminal), always, never")
+	flagFormat   = flag.String("format", formatHuman, "output format: human, json, jsonl, table, csv, sarif, junit")
+	flagWidth    = flag.Int("width", 60, "truncate titles to N characters in -format=table (0 means no truncation)")
+	flagTemplate = flag.String("template", "",
+		"execute the Go text/template for every crash instead of printing it (e.g. {{.Title}})")
+	flagTemplateFile = flag.String("template-file", "", "same as -template, but read the template from the file")
+	flagProgramOnly  = flag.Bool("program-only", false,
+		"print only the syz programs that were executed last before the crashes")
+	flagExecutorOnly = flag.Bool("executor-only", false,
+		"keep only crashes of syz-executor processes (with executor info)")
+	flagRequireRepro = flag.Bool("require-repro", false,
+		"drop crashes from logs that do not contain a C or syz reproducer")
+	flagDiff = flag.String("diff", "", "compare crashes of the input logs (A) with crashes of the given log (B)")
+	flagJobs = flag.Int("jobs", runtime.NumCPU(), "number of logs to parse in parallel")
+	flagMmap = flag.Bool("mmap", false,
+		"map local log files into memory instead of reading them (for very large logs)")
+	flagFollow   = flag.Bool("follow", false, "keep parsing the log file as it grows and print new crashes as JSON lines")
+	flagWatchDir = flag.String("watch-dir", "",
+		"keep parsing new files that appear in the directory and print their crashes as JSON lines")
+	flagDoneDir    = flag.String("done-dir", "", "move the files parsed with -watch-dir to the directory")
+	flagWatchQuiet = flag.Duration("watch-quiet", followSettleTime,
+		"parse -watch-dir files once they have not changed for this long")
+	flagStripTimestamps = flag.Bool("strip-timestamps", false,
+		"remove console timestamps from the beginning of report body and context lines")
+	flagTimestampRegexp = flag.String("timestamp-regexp", logparser.DefaultTimestampRegexp,
+		"regexp matching timestamps stripped by -strip-timestamps")
+	flagAnonymize = flag.Bool("anonymize", false,
+		"redact IP and MAC addresses, host and user names in report bodies, context lines and raw ranges")
+	flagAnonymizePatterns = flag.String("anonymize-patterns", "",
+		"file with additional redaction regexps, one per line, "+
+			"optionally followed by \" => placeholder\" (implies -anonymize)")
+	flagDemangle     = flag.Bool("demangle", false, "demangle C++ and Rust symbols in crash frames")
+	flagNoBody       = flag.Bool("no-body", false, "omit report bodies from output (metadata only)")
+	flagMaxBodyBytes = flag.Int("max-body-bytes", 0,
+		"truncate report bodies longer than N bytes at a line boundary and set body_truncated (0 means unlimited)")
+	flagHexOffsets = flag.Bool("hex-offsets", false,
+		"print crash byte ranges in hex (in the human Range: line and additional *_pos_hex JSON fields)")
+	flagWarnTruncated = flag.Bool("warn-truncated", false,
+		"print a note to stderr for crashes cut off by the end of the log")
+	flagFailOn = failOnList("fail-on",
+		"exit with code 4 if the condition holds for the crashes, "+
+			"e.g. type=KASAN, severity>=error, title~regexp, count>5 (can be repeated, any must hold)")
+	flagVersion     = flag.Bool("version", false, "print the syzkaller revision and Go version and exit")
+	flagListTargets = flag.Bool("list-targets", false, "print all supported OS/arch targets and exit")
+	flagListTypes   = flag.Bool("list-types", false, "print all crash report types and exit")
+	flagQuiet       = flag.Bool("quiet", false,
+		"do not print informational messages (e.g. about logs without crashes), rely on the exit code")
+	flagProgress = flag.Bool("progress", false, "periodically print the number of parsed logs to stderr")
+	flagTiming   = flag.Bool("timing", false,
+		"print the time spent reading, parsing, symbolizing and serializing every log and the totals to stderr")
+	flagVerbose  = flag.Bool("v", false, "print parsing diagnostics for every log to stderr")
+	flagShowSkip = flag.Bool("show-skip", false,
+		"print where parsing resumes after every crash (the bytes between end_pos and skip_pos) to stderr")
+	flagForce     = flag.Bool("force", false, "parse inputs that do not look like text logs (mostly non-printable bytes)")
+	flagBase64    = flag.Bool("base64", false, "treat the single argument as base64-encoded log data instead of a path")
+	flagFilesFrom = flag.String("files-from", "",
+		"also parse the logs listed in the file, one per line (# starts a comment, - reads the list from stdin)")
+	flagGlob     = flag.String("glob", "", "also parse all files matching the pattern (** matches any subdirectory)")
+	flagSplitDir = flag.String("split-dir", "",
+		"write the report body of every crash to a separate file in the directory (human output lists the files)")
+	flagEmitEmpty     = flag.Bool("emit-empty", false, "list logs without crashes in empty_logs of -json-envelope")
+	flagIncludeRawLog = flag.Bool("include-raw-log", false,
+		"embed the whole input logs in the -json-envelope output (large)")
+	flagOutput = flag.String("o", "", "write output to the file instead of stdout")
 )
 
-type serializedReport struct {
-	Title           string               `json:"title"`
-	AltTitles       []string             `json:"alt_titles,omitempty"`
-	Type            string               `json:"type"`
-	Frame           string               `json:"frame,omitempty"`
-	StartPos        int                  `json:"start_pos"`
-	EndPos          int                  `json:"end_pos"`
-	SkipPos         int                  `json:"skip_pos"`
-	Suppressed      bool                 `json:"suppressed"`
-	Corrupted       bool                 `json:"corrupted"`
-	CorruptedReason string               `json:"corrupted_reason,omitempty"`
-	Executor        *report.ExecutorInfo `json:"executor,omitempty"`
-	Report          string               `json:"report"`
+// Process exit codes.
+const (
+	// exitOK means that at least one crash that is neither suppressed nor corrupted was found.
+	exitOK = 0
+	// exitFailure is used for usage and I/O errors (same as tool.Fail).
+	exitFailure = 1
+	// exitNoCrashes means that the logs were parsed, but contain no crashes (or only suppressed ones).
+	exitNoCrashes = 2
+	// exitCorrupted means that all crashes found in the logs are corrupted or suppressed
+	// (corrupted crashes dropped by -exclude-corrupted count as well).
+	exitCorrupted = 3
+	// exitFailOn means that a -fail-on condition holds for the crashes
+	// (with -fail-on the other crash-related codes are replaced by exitOK).
+	exitFailOn = 4
+	// exitPartialFailure means that some of the inputs failed to read or parse,
+	// but the others were parsed and their crashes were output.
+	exitPartialFailure = 5
+)
+
+// parsedLog holds the crashes extracted from a single input log.
+type parsedLog struct {
+	source  string
+	crashes []*logparser.Report
+	// suppressed is set if the log matched suppression patterns of the target.
+	suppressed bool
+	// diag is collected with -v.
+	diag *logDiagnostics
+	// skips describe where parsing resumed after every crash with -show-skip.
+	skips []string
+	// rawLog is a copy of the whole (decompressed) log with -include-raw-log.
+	rawLog []byte
+	// timing is printed with -timing.
+	timing fileTiming
 }
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "usage: syz-logparser [flags] kernel_log_file\n")
+	fmt.Fprintf(os.Stderr, "usage: syz-logparser [flags] [kernel_log_file...]\n")
+	fmt.Fprintf(os.Stderr, "the log is read from stdin if kernel_log_file is omitted or is \"-\"\n")
+	fmt.Fprintf(os.Stderr, "and fetched over the network if it is an http:// or https:// URL\n")
+	fmt.Fprintf(os.Stderr, "exit codes:\n")
+	fmt.Fprintf(os.Stderr, "  %v - found a crash that is neither suppressed nor corrupted\n", exitOK)
+	fmt.Fprintf(os.Stderr, "  %v - usage or I/O error\n", exitFailure)
+	fmt.Fprintf(os.Stderr, "  %v - no crashes found (or only suppressed ones)\n", exitNoCrashes)
+	fmt.Fprintf(os.Stderr, "  %v - only corrupted (or suppressed) crashes found\n", exitCorrupted)
+	fmt.Fprintf(os.Stderr, "  %v - a -fail-on condition holds (with -fail-on, 0 otherwise)\n", exitFailOn)
+	fmt.Fprintf(os.Stderr, "  %v - some inputs failed, but the others were parsed\n", exitPartialFailure)
 	flag.PrintDefaults()
 }
 
 func main() {
+	start := time.Now()
 	flag.Usage = usage
-	flag.Parse()
-	if flag.NArg() != 1 {
-		flag.Usage()
-		os.Exit(1)
+	// The flag package uses exit code 2 for usage errors, which we use for "no crashes".
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(exitOK)
+		}
+		os.Exit(exitFailure)
+	}
+	// Informational flags don't need any input, so they are handled before everything else.
+	if *flagVersion {
+		printVersion(os.Stdout)
+		os.Exit(exitOK)
+	}
+	if *flagListTargets || *flagListTypes {
+		if *flagListTargets {
+			printTargets(os.Stdout)
+		}
+		if *flagListTypes {
+			printTypes(os.Stdout)
+		}
+		os.Exit(exitOK)
+	}
+	if err := checkFlags(); err != nil {
+		tool.Fail(err)
+	}
+	paths, err := inputPaths()
+	if err != nil {
+		tool.Fail(err)
 	}
-	cfg, err := loadReporterConfig()
+	format, err := outputFormat()
 	if err != nil {
-		tool.Failf("failed to load config: %v", err)
+		tool.Fail(err)
 	}
-	reporter, err := report.NewReporter(cfg)
+	tmpl, err := loadTemplate()
 	if err != nil {
-		tool.Failf("failed to create reporter: %v", err)
+		tool.Fail(err)
 	}
-	logData, err := os.ReadFile(flag.Arg(0))
+	filters, err := buildFilters()
 	if err != nil {
-		tool.Failf("failed to read log file: %v", err)
+		tool.Fail(err)
 	}
-	reports := parseReports(reporter, logData)
-	if len(reports) == 0 {
-		if *flagJSON {
-			fmt.Fprintln(os.Stdout, "[]")
-			return
+	var out io.Writer = os.Stdout
+	var outFile *os.File
+	if *flagOutput != "" {
+		outFile, err = os.Create(*flagOutput)
+		if err != nil {
+			tool.Failf("failed to create output file: %v", err)
 		}
-		fmt.Println("no crash reports found in log")
-		if report.IsSuppressed(reporter, logData) {
-			fmt.Println("note: log matched suppression patterns for this target")
+		out = outFile
+	}
+	useColor, err = colorEnabled(*flagColor, out)
+	if err != nil {
+		tool.Fail(err)
+	}
+	opts := parserOptions()
+	var configs *configDir
+	if *flagConfigDir != "" {
+		configs, err = loadConfigDir(*flagConfigDir)
+		if err != nil {
+			tool.Fail(err)
 		}
-		return
+		opts.Config = configs.config(flagTarget(), os.Stderr)
+	}
+	lp, err := logparser.NewParser(opts)
+	if err != nil {
+		tool.Fail(err)
+	}
+	if *flagCheckConfig {
+		fmt.Printf("config %v is valid, target %v\n", *flagConfig, lp.Target())
+		os.Exit(exitOK)
+	}
+	if *flagDryRun {
+		os.Exit(dryRun(os.Stdout, os.Stderr, paths, lp.Target()))
+	}
+	parser := &logParser{parser: lp, showSkip: *flagShowSkip, includeRawLog: *flagIncludeRawLog}
+	if *flagAutoTarget {
+		var messages io.Writer = os.Stderr
+		if *flagQuiet {
+			messages = nil
+		}
+		parser.autoTarget = newAutoTarget(opts, configs, lp, messages)
+	}
+	if *flagVerbose {
+		parser.bootRe, err = regexp.Compile(*flagBootRegexp)
+		if err != nil {
+			tool.Failf("bad -boot-regexp: %v", err)
+		}
+	}
+	removed := make(map[string]int)
+	if *flagFollow {
+		tally, err := parser.follow(out, paths[0], filters, removed)
+		if err != nil {
+			tool.Failf("%v: %v", paths[0], err)
+		}
+		closeOutput(outFile)
+		os.Exit(tally.status(removed))
+	}
+	if *flagWatchDir != "" {
+		tally, err := parser.watch(out, *flagWatchDir, *flagDoneDir, *flagWatchQuiet, filters, removed)
+		if err != nil {
+			tool.Fail(err)
+		}
+		closeOutput(outFile)
+		os.Exit(tally.status(removed))
+	}
+	var logs []*parsedLog
+	var timing fileTiming
+	var prog *progress
+	if *flagProgress {
+		prog = newProgress(os.Stderr, len(paths))
+	}
+	var errs []*logError
+	for _, res := range parseLogs(parser, paths, *flagJobs, prog) {
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", inputName(res.path), res.err)
+			errs = append(errs, &logError{SourceFile: inputName(res.path), Error: res.err.Error()})
+			continue
+		}
+		parsed := res.parsed
+		if *flagTiming {
+			fmt.Fprintf(os.Stderr, "timing: %v: %v\n", sourceName(parsed.source), parsed.timing)
+			timing.add(parsed.timing)
+		}
+		processLog(lp, parsed, filters, removed)
+		logs = append(logs, parsed)
+	}
+	if (*flagGlob != "" || *flagFilesFrom != "") && !*flagQuiet {
+		withCrashes := 0
+		for _, parsed := range logs {
+			if len(parsed.crashes) != 0 {
+				withCrashes++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "parsed %v files: %v with crash reports, %v without, %v unreadable\n",
+			len(paths), withCrashes, len(logs)-withCrashes, len(paths)-len(logs))
 	}
-	if *flagJSON {
-		emitJSON(reports)
+	if len(logs) == 0 {
+		if format == formatJSON && *flagJSONEnvelope && *flagDiff == "" {
+			// Still tell the consumer what went wrong.
+			emitJSONEnvelope(out, nil, &emitOptions{format: format, target: lp.Target(), errors: errs})
+			closeOutput(outFile)
+		}
+		os.Exit(exitFailure)
+	}
+	parsedFiles := len(logs)
+	if *flagDedupAcrossFiles {
+		logs = dedupAcrossLogs(logs)
+	}
+	outputStart := time.Now()
+	if *flagDiff != "" {
+		other, err := parser.parseLog(*flagDiff)
+		if err != nil {
+			tool.Failf("%v: %v", *flagDiff, err)
+		}
+		// B goes through the same filters, dedup and sorting as A, but its removed crashes
+		// don't affect the exit code.
+		processLog(lp, other, filters, make(map[string]int))
+		emitDiff(out, diffCrashes(logs, []*parsedLog{other}), format)
+	} else {
+		total := countCrashes(logs)
+		if *flagReverse {
+			reverseCrashes(logs)
+		}
+		// The exit code is computed for all crashes, not only for the printed page.
+		page := limitCrashes(skipCrashes(logs, *flagOffset), *flagLimit)
+		if *flagSplitDir != "" {
+			if err := splitCrashes(page, *flagSplitDir); err != nil {
+				tool.Fail(err)
+			}
+		}
+		emit(out, page, &emitOptions{
+			format:    format,
+			tmpl:      tmpl,
+			multiFile: len(paths) > 1,
+			target:    lp.Target(),
+			total:     total,
+			errors:    errs,
+		})
+	}
+	closeOutput(outFile)
+	if *flagTiming {
+		printTotalTiming(os.Stderr, parsedFiles, timing, time.Since(outputStart), time.Since(start))
+	}
+	status := finalStatus(logs, removed)
+	if len(errs) != 0 && status != exitFailOn {
+		status = exitPartialFailure
+	}
+	os.Exit(status)
+}
+
+func closeOutput(outFile *os.File) {
+	if outFile == nil {
 		return
 	}
-	printHuman(reports)
+	if err := outFile.Close(); err != nil {
+		tool.Failf("failed to write output file: %v", err)
+	}
+}
+
+// finalStatus returns the process exit code for the crashes of logs, see crashTally.status.
+func finalStatus(logs []*parsedLog, removed map[string]int) int {
+	return tallyLogs(logs, *flagFailOn).status(removed)
+}
+
+// exitStatus returns the process exit code for the crashes that remain after filtering.
+// removed holds the number of crashes dropped by each filter.
+func exitStatus(logs []*parsedLog, removed map[string]int) int {
+	return tallyLogs(logs, nil).exitStatus(removed)
+}
+
+// crashTally accumulates what the exit code depends on, so that -follow and -watch-dir
+// don't need to keep the crashes they have printed.
+type crashTally struct {
+	failOn failOnFlag
+	// count is the number of crashes (including the -dedup counts).
+	count int
+	// clean is set if there are crashes that are neither suppressed nor corrupted.
+	clean     bool
+	corrupted bool
+	// matched[i] is set if the crash condition failOn[i] holds for any crash.
+	matched []bool
+}
+
+func newCrashTally(failOn failOnFlag) *crashTally {
+	return &crashTally{failOn: failOn, matched: make([]bool, len(failOn))}
+}
+
+func tallyLogs(logs []*parsedLog, failOn failOnFlag) *crashTally {
+	tally := newCrashTally(failOn)
+	for _, parsed := range logs {
+		tally.add(parsed.crashes)
+	}
+	return tally
 }
 
-func parseReports(reporter *report.Reporter, logData []byte) []*report.Report {
-	if *flagAll {
-		return report.ParseAll(reporter, logData)
+func (tally *crashTally) add(crashes []*logparser.Report) {
+	for _, rep := range crashes {
+		tally.count += max(rep.Count, 1)
+		if rep.Corrupted {
+			tally.corrupted = true
+		} else if !rep.Suppressed {
+			tally.clean = true
+		}
+		for i, cond := range tally.failOn {
+			if cond.crash != nil && cond.crash(rep) {
+				tally.matched[i] = true
+			}
+		}
 	}
-	if rep := reporter.Parse(logData); rep != nil {
-		return []*report.Report{rep}
+}
+
+// failed returns the first -fail-on condition that holds for the crashes, or nil.
+func (tally *crashTally) failed() *failCondition {
+	for i, cond := range tally.failOn {
+		if tally.matched[i] || cond.total != nil && cond.total(tally.count) {
+			return cond
+		}
 	}
 	return nil
 }
 
-func loadReporterConfig() (*mgrconfig.Config, error) {
-	cfg := mgrconfig.DefaultValues()
-	if *flagConfig != "" {
-		if err := config.LoadFile(*flagConfig, cfg); err != nil {
-			return nil, err
-		}
-	}
-	targetOS, targetVMArch, targetArch := *flagOS, *flagArch, *flagArch
-	if cfg.RawTarget != "" {
-		if parts := strings.Split(cfg.RawTarget, "/"); len(parts) >= 2 {
-			targetOS = parts[0]
-			targetVMArch = parts[1]
-			targetArch = parts[len(parts)-1]
-		}
-	}
-	sysTarget := targets.Get(targetOS, targetVMArch)
-	if sysTarget == nil {
-		return nil, fmt.Errorf("unknown target: %s/%s (supported: %v)", targetOS, targetVMArch, targets.List)
-	}
-	cfg.RawTarget = fmt.Sprintf("%s/%s", targetOS, targetVMArch)
-	cfg.Derived.TargetOS = targetOS
-	cfg.Derived.TargetArch = targetArch
-	cfg.Derived.TargetVMArch = targetVMArch
-	cfg.Derived.SysTarget = sysTarget
-	cfg.CompleteKernelDirs()
-	return cfg, nil
-}
-
-func emitJSON(reports []*report.Report) {
-	out := make([]serializedReport, len(reports))
-	for i, rep := range reports {
-		out[i] = serializedReport{
-			Title:           rep.Title,
-			AltTitles:       rep.AltTitles,
-			Type:            rep.Type.String(),
-			Frame:           rep.Frame,
-			StartPos:        rep.StartPos,
-			EndPos:          rep.EndPos,
-			SkipPos:         rep.SkipPos,
-			Suppressed:      rep.Suppressed,
-			Corrupted:       rep.Corrupted,
-			CorruptedReason: rep.CorruptedReason,
-			Executor:        rep.Executor,
-			Report:          string(rep.Report),
-		}
-	}
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(out); err != nil {
-		tool.Fail(err)
+// status returns the process exit code: the -fail-on result if there are any
+// conditions, or exitStatus otherwise.
+func (tally *crashTally) status(removed map[string]int) int {
+	if len(tally.failOn) == 0 {
+		return tally.exitStatus(removed)
+	}
+	if cond := tally.failed(); cond != nil {
+		if !*flagQuiet {
+			fmt.Fprintf(os.Stderr, "-fail-on condition %q holds\n", cond.text)
+		}
+		return exitFailOn
+	}
+	return exitOK
+}
+
+func (tally *crashTally) exitStatus(removed map[string]int) int {
+	switch {
+	case tally.clean:
+		return exitOK
+	case tally.corrupted || removed["exclude-corrupted"] != 0:
+		return exitCorrupted
+	default:
+		return exitNoCrashes
 	}
 }
 
-func printHuman(reports []*report.Report) {
-	for idx, rep := range reports {
-		fmt.Printf("Crash #%d\n", idx+1)
-		fmt.Printf("Title: %s\n", rep.Title)
-		fmt.Printf("Type: %s\n", rep.Type.String())
-		if len(rep.AltTitles) > 0 {
-			fmt.Printf("Alt titles: %s\n", strings.Join(rep.AltTitles, ", "))
+// checkFlags verifies that the combination of command line flags makes sense.
+func checkFlags() error {
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if format != formatHuman && (*flagTemplate != "" || *flagTemplateFile != "") {
+		return fmt.Errorf("-template conflicts with -format=%v", format)
+	}
+	if err := checkFlagConflicts(); err != nil {
+		return err
+	}
+	if *flagDiff != "" {
+		if format != formatHuman && format != formatJSON && format != formatJSONL {
+			return fmt.Errorf("-diff supports only human, json and jsonl formats")
 		}
-		if rep.Frame != "" {
-			fmt.Printf("Frame: %s\n", rep.Frame)
+	}
+	if *flagFollow {
+		if format != formatHuman && format != formatJSONL {
+			return fmt.Errorf("-follow always emits JSON lines, -format=%v is not supported", format)
 		}
-		fmt.Printf("Range: [%d, %d], next %d\n", rep.StartPos, rep.EndPos, rep.SkipPos)
-		fmt.Printf("Suppressed: %v\n", rep.Suppressed)
-		fmt.Printf("Corrupted: %v", rep.Corrupted)
-		if rep.CorruptedReason != "" {
-			fmt.Printf(" (%s)", rep.CorruptedReason)
+		if len(flag.Args()) != 1 || flag.Arg(0) == "-" || isURL(flag.Arg(0)) {
+			return fmt.Errorf("-follow requires exactly one local log file")
 		}
-		fmt.Printf("\n\n")
-		body := rep.Report
-		if len(body) == 0 {
-			fmt.Printf("(empty report body)\n")
-		} else {
-			if _, err := os.Stdout.Write(body); err != nil {
-				tool.Fail(err)
+	}
+	if *flagWatchDir != "" {
+		if format != formatHuman && format != formatJSONL {
+			return fmt.Errorf("-watch-dir always emits JSON lines, -format=%v is not supported", format)
+		}
+		if len(flag.Args()) != 0 {
+			return fmt.Errorf("-watch-dir doesn't accept log files")
+		}
+		if *flagDoneDir != "" {
+			if err := checkDoneDir(*flagWatchDir, *flagDoneDir); err != nil {
+				return err
 			}
-			if body[len(body)-1] != '\n' {
-				fmt.Printf("\n")
+		}
+	} else if *flagDoneDir != "" || isFlagSet("watch-quiet") {
+		return fmt.Errorf("-done-dir and -watch-quiet require -watch-dir")
+	}
+	if *flagBase64 && len(flag.Args()) != 1 {
+		return fmt.Errorf("-base64 requires exactly one argument")
+	}
+	if *flagIncludeRawLog && !*flagJSONEnvelope {
+		return fmt.Errorf("-include-raw-log requires -json-envelope")
+	}
+	if *flagJSONCompact && format != formatJSON && format != formatSARIF {
+		return fmt.Errorf("-json-compact requires -json, -json-envelope or -format=sarif")
+	}
+	if isFlagSet("indent") && format != formatJSON && format != formatSARIF {
+		return fmt.Errorf("-indent requires -json, -json-envelope or -format=sarif")
+	}
+	if *flagIndent < 0 {
+		return fmt.Errorf("-indent must not be negative")
+	}
+	if *flagJSONCompact && isFlagSet("indent") && *flagIndent != 0 {
+		return fmt.Errorf("-json-compact conflicts with -indent=%v", *flagIndent)
+	}
+	if *flagEmitEmpty && !*flagJSONEnvelope {
+		return fmt.Errorf("-emit-empty requires -json-envelope")
+	}
+	if *flagMergeAltTitles && !*flagDedup {
+		return fmt.Errorf("-merge-alt-titles requires -dedup")
+	}
+	if err := checkSortKey(*flagSort); err != nil {
+		return err
+	}
+	if err := checkGroupKey(*flagGroupBy); err != nil {
+		return err
+	}
+	if *flagOffset < 0 {
+		return fmt.Errorf("-offset must not be negative")
+	}
+	if *flagJobs < 1 {
+		return fmt.Errorf("-jobs must be positive")
+	}
+	if *flagCheckConfig && *flagConfig == "" {
+		return fmt.Errorf("-check-config requires -config")
+	}
+	if *flagWidth < 0 {
+		return fmt.Errorf("-width must not be negative")
+	}
+	if *flagMaxBodyBytes < 0 {
+		return fmt.Errorf("-max-body-bytes must not be negative")
+	}
+	if _, err := colorEnabled(*flagColor, nil); err != nil {
+		return err
+	}
+	if *flagMaintainers {
+		src := kernelSrcDir()
+		if src == "" {
+			return fmt.Errorf("-maintainers requires -kernel-src or -kernel-obj")
+		}
+		script := filepath.Join(src, "scripts", "get_maintainer.pl")
+		if !osutil.IsExist(script) {
+			return fmt.Errorf("kernel sources %v do not contain %v", src, script)
+		}
+	}
+	return nil
+}
+
+// kernelSrcDir returns the kernel source dir: -kernel-src, or the kernel obj dir for in-tree builds
+// (the reporter config defaults to it the same way).
+func kernelSrcDir() string {
+	switch {
+	case *flagKernelSrc != "":
+		return *flagKernelSrc
+	case *flagKernelObj != "":
+		return *flagKernelObj
+	case *flagVmlinux != "":
+		return filepath.Dir(*flagVmlinux)
+	}
+	return ""
+}
+
+// flagConflicts lists the flags that can't be combined with each of the flags.
+var flagConflicts = []struct {
+	flag      string
+	conflicts []string
+}{
+	{"diff", []string{"count", "titles", "stats", "group-by", "program-only", "report-only", "template",
+		"template-file", "reverse", "split-dir", "json-envelope"}},
+	{"follow", []string{"diff", "count", "titles", "stats", "group-by", "program-only", "report-only", "template",
+		"template-file", "per-boot", "nth", "glob", "files-from", "sort", "reverse", "base64", "auto-target",
+		"timing", "split-dir", "offset", "limit", "dedup", "dedup-across-files", "show-skip", "warn-truncated"}},
+	{"watch-dir", []string{"follow", "diff", "count", "titles", "stats", "group-by", "program-only",
+		"report-only", "template", "template-file", "glob", "files-from", "base64", "dedup-across-files",
+		"reverse", "offset", "limit", "nth", "split-dir", "timing"}},
+	{"base64", []string{"glob", "files-from", "diff"}},
+	{"report-only", []string{"no-body"}},
+	{"config-dir", []string{"config"}},
+	{"auto-target", []string{"config"}},
+	{"split-dir", []string{"no-body"}},
+}
+
+// checkFlagConflicts checks that no two flags of flagConflicts are used together.
+func checkFlagConflicts() error {
+	for _, entry := range flagConflicts {
+		if !isFlagUsed(entry.flag) {
+			continue
+		}
+		for _, name := range entry.conflicts {
+			if isFlagUsed(name) {
+				return fmt.Errorf("-%v can't be combined with -%v", entry.flag, name)
 			}
 		}
-		if idx+1 < len(reports) {
-			fmt.Printf("\n---\n\n")
+	}
+	return nil
+}
+
+// isFlagUsed returns whether the flag has a non-default value.
+func isFlagUsed(name string) bool {
+	f := flag.Lookup(name)
+	return f.Value.String() != f.DefValue
+}
+
+// isFlagSet returns whether the flag was explicitly given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// parserOptions returns the parsing options requested on the command line.
+func parserOptions() *logparser.Options {
+	opts := &logparser.Options{
+		Config:            *flagConfig,
+		LogFormat:         *flagLogFormat,
+		StrictTarget:      *flagStrictTarget,
+		Suppressions:      *flagSuppressions,
+		ReporterPreset:    *flagReporterPreset,
+		All:               *flagAll,
+		Nth:               *flagNth,
+		Vmlinux:           *flagVmlinux,
+		KernelObj:         *flagKernelObj,
+		KernelSrc:         *flagKernelSrc,
+		Maintainers:       *flagMaintainers,
+		FingerprintFields: *flagFingerprintFields,
+		Context:           *flagContext,
+		// -ignore matches raw ranges, they are dropped after filtering unless requested.
+		RawRange:          *flagRawRange || len(*flagIgnore) != 0,
+		NoBody:            *flagNoBody,
+		MaxBodyBytes:      *flagMaxBodyBytes,
+		HexOffsets:        *flagHexOffsets,
+		PerBoot:           *flagPerBoot,
+		BootRegexp:        *flagBootRegexp,
+		StripTimestamps:   *flagStripTimestamps,
+		TimestampRegexp:   *flagTimestampRegexp,
+		Anonymize:         *flagAnonymize,
+		AnonymizePatterns: *flagAnonymizePatterns,
+		Demangle:          *flagDemangle,
+		Warnings:          os.Stderr,
+	}
+	// The config target overrides only explicitly given -os/-arch/-vmarch with a warning.
+	if isFlagSet("os") {
+		opts.OS = *flagOS
+	}
+	if isFlagSet("arch") {
+		opts.Arch = *flagArch
+	}
+	if isFlagSet("vmarch") {
+		opts.VMArch = *flagVMArch
+	}
+	return opts
+}
+
+// processLog prints the per-log messages (-show-skip, -warn-truncated, -v) and applies
+// filters (removed is updated with the number of crashes dropped by each filter),
+// -dedup and -sort to the crashes of the log.
+func processLog(lp *logparser.Parser, parsed *parsedLog, filters []crashFilter, removed map[string]int) {
+	for idx, skip := range parsed.skips {
+		fmt.Fprintf(os.Stderr, "%v: crash #%d %v\n", sourceName(parsed.source), idx+1, skip)
+	}
+	if *flagWarnTruncated {
+		for idx, crash := range parsed.crashes {
+			if crash.Truncated {
+				fmt.Fprintf(os.Stderr, "warning: %v: crash #%d %q is truncated by the end of the log\n",
+					sourceName(parsed.source), idx+1, crash.Title)
+			}
+		}
+	}
+	logRemoved := make(map[string]int)
+	parsed.crashes = filterCrashes(parsed.crashes, filters, logRemoved)
+	dropRawRanges(parsed.crashes)
+	for name, n := range logRemoved {
+		removed[name] += n
+	}
+	if parsed.diag != nil {
+		parsed.diag.removed = logRemoved
+	}
+	if *flagDedup {
+		before := len(parsed.crashes)
+		if *flagMergeAltTitles {
+			parsed.crashes = mergeAltTitles(parsed.crashes)
+			for _, rep := range parsed.crashes {
+				rep.NormalizedTitle = logparser.NormalizeTitle(rep.Title)
+				rep.Fingerprint = lp.Fingerprint(rep)
+			}
+		} else {
+			parsed.crashes = dedupCrashes(parsed.crashes)
+		}
+		if parsed.diag != nil {
+			parsed.diag.deduped = before - len(parsed.crashes)
+		}
+	}
+	if *flagSort != "" {
+		sortCrashes(parsed.crashes, *flagSort)
+	}
+	if parsed.diag != nil {
+		parsed.diag.print(os.Stderr, parsed.source, len(parsed.crashes))
+	}
+}
+
+// logParser reads logs and extracts crashes from them.
+type logParser struct {
+	parser *logparser.Parser
+	// bootRe matches boot banners counted with -v.
+	bootRe *regexp.Regexp
+	// showSkip describes where parsing resumes after every crash.
+	showSkip bool
+	// includeRawLog keeps a copy of every log.
+	includeRawLog bool
+	// autoTarget selects the parser of every log with -auto-target.
+	autoTarget *autoTarget
+}
+
+func (p *logParser) parseLog(path string) (*parsedLog, error) {
+	start := time.Now()
+	logData, release, err := readLog(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	read := time.Since(start)
+	// All crash fields are copied out of the log, so it's not referenced after parsing.
+	defer release()
+	source := path
+	if path == "-" || *flagBase64 {
+		source = ""
+	}
+	parser := p.parser
+	if p.autoTarget != nil {
+		parser = p.autoTarget.parserFor(logData, inputName(path))
+	}
+	res, err := parser.ParseLog(logData, source)
+	if err != nil {
+		return nil, err
+	}
+	parsed := &parsedLog{
+		source:     source,
+		crashes:    res.Crashes,
+		suppressed: res.Suppressed,
+		timing:     fileTiming{read: read, Timing: res.Timing},
+	}
+	if p.includeRawLog {
+		parsed.rawLog = parser.Anonymize(bytes.Clone(logData))
+	}
+	if p.showSkip {
+		for _, crash := range parsed.crashes {
+			parsed.skips = append(parsed.skips, describeSkip(logData, crash))
+		}
+	}
+	if p.bootRe != nil {
+		parsed.diag = &logDiagnostics{
+			size:  len(logData),
+			boots: len(p.bootRe.FindAllIndex(logData, -1)),
+			found: res.Found,
 		}
 	}
+	return parsed, nil
 }
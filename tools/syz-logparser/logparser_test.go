@@ -0,0 +1,311 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+// runToolEnv makes the test binary run the tool instead of the tests, see runTool.
+const runToolEnv = "SYZ_LOGPARSER_RUN_TOOL"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runToolEnv) != "" {
+		main()
+		os.Exit(exitOK)
+	}
+	os.Exit(m.Run())
+}
+
+// runTool runs the tool (the test binary itself) with the given stdin and args
+// and returns its stdout, stderr and exit code.
+func runTool(t *testing.T, stdin string, args ...string) (string, string, int) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runToolEnv+"=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// testCrashLog is a log with a single complete crash report, "WARNING in bar".
+const testCrashLog = "[   10.000000] ------------[ cut here ]------------\n" +
+	"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+	"[   10.000000] Call Trace:\n" +
+	"[   10.000000]  bar+0x1/0x2\n" +
+	"[   10.000000] ---[ end trace 0000000000000000 ]---\n"
+
+func TestMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.log")
+	missing := filepath.Join(dir, "missing.log")
+	assert.NoError(t, os.WriteFile(good, []byte(testCrashLog), 0644))
+	stdout, stderr, code := runTool(t, "", "-arch", "amd64", good, missing)
+	// The missing file is reported and skipped, the other one is still parsed.
+	assert.Equal(t, exitPartialFailure, code)
+	assert.True(t, strings.HasPrefix(stdout, "=== "+good+" ===\n\nCrash #1\nTitle: WARNING in bar\n"), stdout)
+	assert.NotContains(t, stdout, missing)
+	assert.Contains(t, stderr, missing+": failed to read log file: ")
+
+	stdout, _, code = runTool(t, "", "-arch", "amd64", good, good)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, 2, strings.Count(stdout, "=== "+good+" ===\n"))
+	_, stderr, code = runTool(t, "", missing)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, missing+": failed to read log file: ")
+}
+
+func TestExitStatus(t *testing.T) {
+	logs := func(crashes ...*logparser.Report) []*parsedLog {
+		return []*parsedLog{{crashes: crashes}}
+	}
+	ok := &logparser.Report{}
+	suppressed := &logparser.Report{Suppressed: true}
+	corrupted := &logparser.Report{Corrupted: true}
+
+	assert.Equal(t, exitOK, exitStatus(logs(corrupted, ok), nil))
+	assert.Equal(t, exitNoCrashes, exitStatus(logs(), nil))
+	assert.Equal(t, exitNoCrashes, exitStatus(logs(suppressed), nil))
+	assert.Equal(t, exitCorrupted, exitStatus(logs(corrupted, suppressed), nil))
+	assert.Equal(t, exitCorrupted, exitStatus(logs(), map[string]int{"exclude-corrupted": 1}))
+}
+
+func TestCrashTally(t *testing.T) {
+	var failOn failOnFlag
+	assert.NoError(t, failOn.Set("type=KASAN"))
+	assert.NoError(t, failOn.Set("count>2"))
+	warning := &logparser.Report{Title: "WARNING in foo", Type: "WARNING"}
+	kasan := &logparser.Report{Title: "KASAN: use-after-free Read in bar", Type: "KASAN-USE-AFTER-FREE-READ"}
+	corrupted := &logparser.Report{Title: "WARNING in baz", Type: "WARNING", Corrupted: true}
+
+	// Crashes added one by one (as -follow and -watch-dir do) give the same result as all at once.
+	tally := newCrashTally(failOn)
+	assert.Nil(t, tally.failed())
+	assert.Equal(t, exitNoCrashes, tally.exitStatus(nil))
+	tally.add([]*logparser.Report{corrupted})
+	assert.Nil(t, tally.failed())
+	assert.Equal(t, exitCorrupted, tally.exitStatus(nil))
+	tally.add([]*logparser.Report{warning})
+	assert.Nil(t, tally.failed())
+	assert.Equal(t, exitOK, tally.exitStatus(nil))
+	tally.add([]*logparser.Report{warning})
+	assert.Equal(t, "count>2", tally.failed().text)
+	tally.add([]*logparser.Report{kasan})
+	assert.Equal(t, "type=KASAN", tally.failed().text)
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{corrupted, warning}},
+		{crashes: []*logparser.Report{warning, kasan}},
+	}
+	assert.Equal(t, failOn.match(logs), tally.failed())
+}
+
+func TestParseEmptyLog(t *testing.T) {
+	parser := newTestParser(t)
+	dir := t.TempDir()
+	defer func() { *flagMmap = false }()
+	for _, data := range []string{"", "\n", "x", "\x1f"} {
+		path := filepath.Join(dir, "log")
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		for _, mmap := range []bool{false, true} {
+			*flagMmap = mmap
+			parsed, err := parser.parseLog(path)
+			assert.NoError(t, err, "%q", data)
+			assert.Empty(t, parsed.crashes, "%q", data)
+			buf := new(bytes.Buffer)
+			printHuman(buf, []*parsedLog{parsed}, false, false)
+			assert.Equal(t, "no crash reports found in log\n", buf.String())
+			assert.Equal(t, exitNoCrashes, exitStatus([]*parsedLog{parsed}, nil))
+		}
+	}
+}
+
+// newTestParser returns a parser for linux/amd64 logs without symbolization.
+func newTestParser(t *testing.T) *logParser {
+	parser, err := logparser.NewParser(&logparser.Options{OS: targets.Linux, Arch: targets.AMD64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &logParser{parser: parser}
+}
+
+func TestFlagConflicts(t *testing.T) {
+	for _, entry := range flagConflicts {
+		assert.NotNil(t, flag.Lookup(entry.flag), entry.flag)
+		for _, name := range entry.conflicts {
+			assert.NotNil(t, flag.Lookup(name), name)
+		}
+	}
+	assert.NoError(t, checkFlagConflicts())
+	assert.NoError(t, flag.Set("follow", "true"))
+	assert.NoError(t, flag.Set("count", "true"))
+	defer func() {
+		*flagFollow = false
+		*flagCount = false
+	}()
+	assert.EqualError(t, checkFlagConflicts(), "-follow can't be combined with -count")
+	*flagCount = false
+	// -follow doesn't run the output selection of processLog.
+	assert.NoError(t, flag.Set("limit", "1"))
+	defer func() { *flagLimit = 0 }()
+	assert.EqualError(t, checkFlagConflicts(), "-follow can't be combined with -limit")
+	*flagFollow = false
+	*flagLimit = 0
+	// -watch-dir processes each file on its own, so there is no n-th crash to pick.
+	assert.NoError(t, flag.Set("watch-dir", t.TempDir()))
+	assert.NoError(t, flag.Set("nth", "2"))
+	defer func() {
+		*flagWatchDir = ""
+		*flagNth = 0
+	}()
+	assert.EqualError(t, checkFlagConflicts(), "-watch-dir can't be combined with -nth")
+	*flagWatchDir = ""
+	*flagNth = 0
+	// -diff prints its own only_a/only_b/common object, not the envelope.
+	assert.NoError(t, flag.Set("diff", "b.log"))
+	assert.NoError(t, flag.Set("json-envelope", "true"))
+	defer func() {
+		*flagDiff = ""
+		*flagJSONEnvelope = false
+	}()
+	assert.EqualError(t, checkFlagConflicts(), "-diff can't be combined with -json-envelope")
+}
+
+func TestMaintainersKernelSrc(t *testing.T) {
+	src := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(src, "scripts"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "scripts", "get_maintainer.pl"), nil, 0755))
+	*flagMaintainers = true
+	defer func() {
+		*flagMaintainers = false
+		*flagKernelSrc = ""
+		*flagKernelObj = ""
+		*flagVmlinux = ""
+	}()
+	assert.EqualError(t, checkFlags(), "-maintainers requires -kernel-src or -kernel-obj")
+	// Without -kernel-src the sources are looked up in the kernel obj dir.
+	*flagKernelObj = src
+	assert.NoError(t, checkFlags())
+	*flagKernelObj = ""
+	*flagVmlinux = filepath.Join(src, "vmlinux")
+	assert.NoError(t, checkFlags())
+	*flagKernelSrc = t.TempDir()
+	assert.Error(t, checkFlags())
+	*flagKernelSrc = src
+	assert.NoError(t, checkFlags())
+}
+
+func TestCheckConfigFlag(t *testing.T) {
+	*flagCheckConfig = true
+	defer func() {
+		*flagCheckConfig = false
+		*flagConfig = ""
+	}()
+	assert.EqualError(t, checkFlags(), "-check-config requires -config")
+	*flagConfig = "cfg.json"
+	assert.NoError(t, checkFlags())
+}
+
+func TestOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "console.log")
+	assert.NoError(t, os.WriteFile(log, []byte(testCrashLog), 0644))
+	want, _, code := runTool(t, "", "-arch", "amd64", "-json", log)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, want, `"title": "WARNING in bar"`)
+
+	out := filepath.Join(dir, "crashes.json")
+	stdout, _, code := runTool(t, "", "-arch", "amd64", "-json", "-o", out, log)
+	assert.Equal(t, exitOK, code)
+	assert.Empty(t, stdout)
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(data))
+
+	// Nothing is written to stdout instead.
+	stdout, stderr, code := runTool(t, "", "-arch", "amd64", "-json", "-o", filepath.Join(dir, "missing", "out"), log)
+	assert.Equal(t, exitFailure, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "failed to create output file: ")
+}
+
+// writeCrashLog writes a log with a "WARNING in <frame>" crash for every frame to a temp file.
+func writeCrashLog(t *testing.T, frames ...string) string {
+	var log strings.Builder
+	for _, frame := range frames {
+		log.WriteString(strings.ReplaceAll(testCrashLog, "bar", frame))
+	}
+	path := filepath.Join(t.TempDir(), "console.log")
+	assert.NoError(t, os.WriteFile(path, []byte(log.String()), 0644))
+	return path
+}
+
+func TestDiff(t *testing.T) {
+	a := writeCrashLog(t, "bar", "qux", "baz")
+	b := writeCrashLog(t, "baz", "qux", "quux", "baz")
+	stdout, stderr, code := runTool(t, "", "-arch", "amd64", "-all", "-diff", b, a)
+	assert.Equal(t, "Only in A (1):\n  WARNING in bar\nOnly in B (1):\n  WARNING in quux\n"+
+		"Common (2):\n  WARNING in qux\n  WARNING in baz\n", stdout, stderr)
+	assert.Equal(t, exitOK, code)
+	// The filter drops qux and quux from both sides.
+	stdout, stderr, code = runTool(t, "", "-arch", "amd64", "-all", "-title-regexp", "in ba[rz]$", "-diff", b, a)
+	assert.Equal(t, "Only in A (1):\n  WARNING in bar\nOnly in B (0):\nCommon (1):\n  WARNING in baz\n",
+		stdout, stderr)
+	assert.Equal(t, exitOK, code)
+}
+
+func TestCount(t *testing.T) {
+	log := writeCrashLog(t, "bar", "baz", "bar")
+	empty := writeCrashLog(t)
+	for _, test := range []struct {
+		args []string
+		want string
+		code int
+	}{
+		{[]string{log}, "1\n", exitOK},
+		{[]string{"-all", log}, "3\n", exitOK},
+		// The count is the one after filters.
+		{[]string{"-all", "-title-regexp", "in bar$", log}, "2\n", exitOK},
+		{[]string{"-all", "-title-regexp", "in qux$", log}, "0\n", exitNoCrashes},
+		{[]string{"-all", empty}, "0\n", exitNoCrashes},
+	} {
+		stdout, stderr, code := runTool(t, "", append([]string{"-arch", "amd64", "-count"}, test.args...)...)
+		assert.Equal(t, test.want, stdout, "%q: %v", test.args, stderr)
+		assert.Equal(t, test.code, code, "%q", test.args)
+	}
+}
+
+func TestTitles(t *testing.T) {
+	log := writeCrashLog(t, "bar", "baz", "bar")
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{log}, "WARNING in bar\n"},
+		// Crashes are listed in the log order, with duplicates.
+		{[]string{"-all", log}, "WARNING in bar\nWARNING in baz\nWARNING in bar\n"},
+		{[]string{"-all", "-dedup", log}, "WARNING in bar\nWARNING in baz\n"},
+		{[]string{"-all", "-title-regexp", "in baz$", log}, "WARNING in baz\n"},
+		{[]string{"-all", log, log}, strings.Repeat("WARNING in bar\nWARNING in baz\nWARNING in bar\n", 2)},
+	} {
+		stdout, stderr, code := runTool(t, "", append([]string{"-arch", "amd64", "-titles"}, test.args...)...)
+		assert.Equal(t, test.want, stdout, "%q: %v", test.args, stderr)
+		assert.Equal(t, exitOK, code, "%q", test.args)
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+//go:build !unix || 386 || arm
+
+package main
+
+import (
+	"os"
+)
+
+// mapFile falls back to reading the whole file on systems without mmap
+// (or with a too small address space to map large logs).
+func mapFile(path string) ([]byte, func(), error) {
+	data, err := os.ReadFile(path)
+	return data, func() {}, err
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+//go:build unix && !386 && !arm
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the file into memory, so that its contents are paged in by the kernel on demand
+// and are not copied into the Go heap. The mapping is private, so accidental writes
+// to the data don't modify the file.
+func mapFile(path string) ([]byte, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !stat.Mode().IsRegular() || stat.Size() == 0 {
+		// Pipes, devices and empty files can't be mapped.
+		data, err := os.ReadFile(path)
+		return data, func() {}, err
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(stat.Size()), syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to mmap: %w", err)
+	}
+	release := func() {
+		if err := syscall.Munmap(data); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to munmap %v: %v\n", path, err)
+		}
+	}
+	return data, release, nil
+}
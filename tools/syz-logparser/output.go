@@ -0,0 +1,460 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+// Output formats supported by -format.
+const (
+	formatHuman = "human"
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatSARIF = "sarif"
+	formatJUnit = "junit"
+)
+
+var outputFormats = []string{formatHuman, formatJSON, formatJSONL, formatTable, formatCSV, formatSARIF, formatJUnit}
+
+// outputFormat returns the output format selected by -format and the -json, -jsonl
+// and -json-envelope shorthands.
+func outputFormat() (string, error) {
+	if *flagJSON && *flagJSONL {
+		return "", fmt.Errorf("-json and -jsonl are mutually exclusive")
+	}
+	format := *flagFormat
+	for _, shorthand := range []struct {
+		flag   string
+		set    bool
+		format string
+	}{
+		{"json", *flagJSON, formatJSON},
+		{"jsonl", *flagJSONL, formatJSONL},
+		{"json-envelope", *flagJSONEnvelope, formatJSON},
+	} {
+		if !shorthand.set {
+			continue
+		}
+		if format != formatHuman && format != shorthand.format {
+			return "", fmt.Errorf("-%v conflicts with -format=%v", shorthand.flag, format)
+		}
+		format = shorthand.format
+	}
+	for _, known := range outputFormats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown -format %q (supported: %v)", format, strings.Join(outputFormats, ", "))
+}
+
+// emitOptions describes the output independently of the crashes.
+type emitOptions struct {
+	format string
+	// tmpl is used instead of format if set.
+	tmpl      *template.Template
+	multiFile bool
+	// target is the OS/arch of the parsed logs.
+	target string
+	// total is the number of crashes before -offset and -limit were applied.
+	total int
+	// errors are the inputs that failed to read or parse.
+	errors []*logError
+}
+
+// emit writes the crashes as selected by opts (unless -count, -titles, -program-only,
+// -report-only, -stats or -group-by is set).
+func emit(w io.Writer, logs []*parsedLog, opts *emitOptions) {
+	switch {
+	case *flagCount:
+		fmt.Fprintln(w, countCrashes(logs))
+	case *flagTitles:
+		printTitles(w, logs)
+	case *flagProgramOnly:
+		printPrograms(w, logs)
+	case *flagReportOnly:
+		printReports(w, logs, *flagReportDelimiter)
+	case *flagStats:
+		emitStats(w, collectStats(logs), opts.format)
+	case *flagGroupBy != "":
+		emitGroups(w, groupCrashes(logs, *flagGroupBy), opts.format)
+	case opts.tmpl != nil:
+		executeTemplate(w, logs, opts.tmpl)
+	case opts.format == formatJSON && *flagJSONEnvelope:
+		emitJSONEnvelope(w, logs, opts)
+	case opts.format == formatJSON:
+		emitJSON(w, logs)
+	case opts.format == formatJSONL:
+		emitJSONL(w, logs)
+	case opts.format == formatTable:
+		printTable(w, logs, opts.multiFile, *flagWidth)
+	case opts.format == formatCSV:
+		emitCSV(w, logs, opts.multiFile)
+	case opts.format == formatSARIF:
+		emitSARIF(w, logs)
+	case opts.format == formatJUnit:
+		emitJUnit(w, logs)
+	case *flagSplitDir != "":
+		printOutputFiles(w, logs)
+	default:
+		printHuman(w, logs, opts.multiFile, *flagQuiet)
+	}
+}
+
+// indentJSON makes enc indent the JSON output by -indent spaces unless -json-compact is given.
+func indentJSON(enc *json.Encoder) {
+	if !*flagJSONCompact && *flagIndent > 0 {
+		enc.SetIndent("", strings.Repeat(" ", *flagIndent))
+	}
+}
+
+func countCrashes(logs []*parsedLog) int {
+	count := 0
+	for _, parsed := range logs {
+		count += len(parsed.crashes)
+	}
+	return count
+}
+
+func emitJSON(w io.Writer, logs []*parsedLog) {
+	out := []*logparser.Report{}
+	for _, parsed := range logs {
+		out = append(out, parsed.crashes...)
+	}
+	enc := json.NewEncoder(w)
+	indentJSON(enc)
+	if err := enc.Encode(out); err != nil {
+		tool.Fail(err)
+	}
+}
+
+// jsonEnvelope wraps crashes with the information about the run that produced them.
+type jsonEnvelope struct {
+	// SchemaVersion is logparser.SchemaVersion. It also covers the envelope fields.
+	SchemaVersion int    `json:"schema_version"`
+	ToolVersion   string `json:"tool_version"`
+	Target        string `json:"target"`
+	// SourceFile is set only if a single log was parsed.
+	SourceFile string    `json:"source_file,omitempty"`
+	ParsedAt   time.Time `json:"parsed_at"`
+	// Total is the number of crashes before Offset and Limit were applied.
+	Total   int                 `json:"total"`
+	Offset  int                 `json:"offset"`
+	Limit   int                 `json:"limit"`
+	Crashes []*logparser.Report `json:"crashes"`
+	// RawLogs are the parsed logs with -include-raw-log.
+	RawLogs []*rawLog `json:"raw_logs,omitempty"`
+	// EmptyLogs are the parsed logs without crashes with -emit-empty.
+	EmptyLogs []*emptyLog `json:"empty_logs,omitempty"`
+	// Errors are the inputs that failed to read or parse.
+	Errors []*logError `json:"errors,omitempty"`
+}
+
+// rawLog is a whole input log embedded in the JSON envelope.
+type rawLog struct {
+	SourceFile string `json:"source_file"`
+	// Encoding is "text" if the log is valid UTF-8 and "base64" otherwise.
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+}
+
+// emptyLog is a parsed log without crashes in the JSON envelope.
+type emptyLog struct {
+	SourceFile string `json:"source_file"`
+	// Suppressed is set if the log matched suppression patterns of the target.
+	Suppressed bool `json:"suppressed"`
+	// Crashes is always empty, for consumers that treat all entries the same way.
+	Crashes []*logparser.Report `json:"crashes"`
+}
+
+// logError is an input that failed to read or parse in the JSON envelope.
+type logError struct {
+	SourceFile string `json:"source_file"`
+	Error      string `json:"error"`
+}
+
+func newRawLog(source string, data []byte) *rawLog {
+	if utf8.Valid(data) {
+		return &rawLog{SourceFile: source, Encoding: "text", Data: string(data)}
+	}
+	return &rawLog{SourceFile: source, Encoding: "base64", Data: base64.StdEncoding.EncodeToString(data)}
+}
+
+func emitJSONEnvelope(w io.Writer, logs []*parsedLog, opts *emitOptions) {
+	out := &jsonEnvelope{
+		SchemaVersion: logparser.SchemaVersion,
+		ToolVersion:   toolVersion(),
+		Target:        opts.target,
+		ParsedAt:      time.Now().UTC(),
+		Total:         opts.total,
+		Offset:        *flagOffset,
+		Limit:         *flagLimit,
+		Crashes:       []*logparser.Report{},
+		Errors:        opts.errors,
+	}
+	if !opts.multiFile && len(logs) == 1 {
+		out.SourceFile = logs[0].source
+	}
+	for _, parsed := range logs {
+		out.Crashes = append(out.Crashes, parsed.crashes...)
+		if *flagIncludeRawLog {
+			out.RawLogs = append(out.RawLogs, newRawLog(parsed.source, parsed.rawLog))
+		}
+		if *flagEmitEmpty && len(parsed.crashes) == 0 {
+			out.EmptyLogs = append(out.EmptyLogs, &emptyLog{
+				SourceFile: parsed.source,
+				Suppressed: parsed.suppressed,
+				Crashes:    []*logparser.Report{},
+			})
+		}
+	}
+	enc := json.NewEncoder(w)
+	indentJSON(enc)
+	if err := enc.Encode(out); err != nil {
+		tool.Fail(err)
+	}
+}
+
+func emitJSONL(w io.Writer, logs []*parsedLog) {
+	enc := json.NewEncoder(w)
+	for _, parsed := range logs {
+		for _, crash := range parsed.crashes {
+			if err := enc.Encode(crash); err != nil {
+				tool.Fail(err)
+			}
+		}
+	}
+}
+
+func printTitles(w io.Writer, logs []*parsedLog) {
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			fmt.Fprintln(w, rep.Title)
+		}
+	}
+}
+
+// printPrograms prints the programs of all crashes separated by empty lines.
+// Crashes without a program are skipped.
+func printPrograms(w io.Writer, logs []*parsedLog) {
+	first := true
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			if rep.Program == "" {
+				continue
+			}
+			if !first {
+				fmt.Fprintf(w, "\n")
+			}
+			first = false
+			if _, err := io.WriteString(w, rep.Program); err != nil {
+				tool.Fail(err)
+			}
+		}
+	}
+}
+
+// printReports prints the report bodies of all crashes separated by delimiter lines.
+// Every body is terminated with a newline.
+func printReports(w io.Writer, logs []*parsedLog, delimiter string) {
+	first := true
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			if !first {
+				fmt.Fprintln(w, delimiter)
+			}
+			first = false
+			if rep.Report != "" {
+				writeLines(w, rep.Report)
+			}
+		}
+	}
+}
+
+// printHuman prints crashes of every log. Logs without crashes are skipped if quiet is set.
+func printHuman(w io.Writer, logs []*parsedLog, multiFile, quiet bool) {
+	first := true
+	for _, parsed := range logs {
+		if quiet && len(parsed.crashes) == 0 {
+			continue
+		}
+		if multiFile {
+			if !first {
+				fmt.Fprintf(w, "\n")
+			}
+			fmt.Fprintf(w, "=== %v ===\n\n", parsed.source)
+		}
+		first = false
+		if len(parsed.crashes) == 0 {
+			fmt.Fprintln(w, "no crash reports found in log")
+			if parsed.suppressed {
+				fmt.Fprintln(w, "note: log matched suppression patterns for this target")
+			}
+			continue
+		}
+		printCrashes(w, parsed.crashes)
+	}
+}
+
+func printCrashes(w io.Writer, crashes []*logparser.Report) {
+	for idx, rep := range crashes {
+		fmt.Fprintf(w, "Crash #%d\n", idx+1)
+		fmt.Fprintf(w, "Title: %s\n", colorize(rep.Title, colorBold))
+		fmt.Fprintf(w, "Type: %s\n", colorize(rep.Type, colorCyan))
+		if len(rep.AltTitles) > 0 {
+			fmt.Fprintf(w, "Alt titles: %s\n", strings.Join(rep.AltTitles, ", "))
+		}
+		if rep.Frame != "" {
+			fmt.Fprintf(w, "Frame: %s\n", rep.Frame)
+		}
+		if rep.GuiltyLine != "" {
+			fmt.Fprintf(w, "Guilty: %s\n", rep.GuiltyLine)
+		}
+		if rep.Executor != nil {
+			fmt.Fprintf(w, "Executor: proc %d, exec %d\n", rep.Executor.ProcID, rep.Executor.ExecID)
+		}
+		if rep.StartPosHex != "" {
+			fmt.Fprintf(w, "Range: [%s, %s], next %s", rep.StartPosHex, rep.EndPosHex, rep.SkipPosHex)
+		} else {
+			fmt.Fprintf(w, "Range: [%d, %d], next %d", rep.StartPos, rep.EndPos, rep.SkipPos)
+		}
+		fmt.Fprintf(w, ", lines %d-%d\n", rep.LineStart, rep.LineEnd)
+		if rep.BootIndex != nil {
+			fmt.Fprintf(w, "Boot: %d\n", *rep.BootIndex)
+		}
+		if len(rep.Maintainers) > 0 {
+			fmt.Fprintf(w, "Maintainers: %s\n", strings.Join(rep.Maintainers, ", "))
+		}
+		if rep.Count != 0 {
+			fmt.Fprintf(w, "Occurrences: %d\n", rep.Count)
+		}
+		if len(rep.Sources) != 0 {
+			fmt.Fprintf(w, "Sources: %s\n", strings.Join(rep.Sources, ", "))
+		}
+		if rep.Truncated {
+			fmt.Fprintf(w, "Truncated: true (the log ends inside of the report)\n")
+		}
+		fmt.Fprintf(w, "Suppressed: %v\n", rep.Suppressed)
+		if rep.Corrupted {
+			fmt.Fprintf(w, "Corrupted: %v", colorize("true", colorRed))
+		} else {
+			fmt.Fprintf(w, "Corrupted: false")
+		}
+		if rep.CorruptedReason != "" {
+			fmt.Fprintf(w, " (%s)", rep.CorruptedReason)
+		}
+		fmt.Fprintf(w, "\n\n")
+		body := rep.ContextBefore + rep.Report + rep.ContextAfter
+		if *flagNoBody {
+			body = rep.ContextBefore + "(body omitted)\n" + rep.ContextAfter
+		}
+		if len(body) == 0 {
+			fmt.Fprintf(w, "(empty report body)\n")
+		} else {
+			writeLines(w, body)
+		}
+		if rep.RawRange != "" {
+			fmt.Fprintf(w, "\nRaw range:\n")
+			writeLines(w, rep.RawRange)
+		}
+		if idx+1 < len(crashes) {
+			fmt.Fprintf(w, "\n---\n\n")
+		}
+	}
+}
+
+// writeLines writes text and terminates its last line if it's not terminated.
+func writeLines(w io.Writer, text string) {
+	if _, err := io.WriteString(w, text); err != nil {
+		tool.Fail(err)
+	}
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// printTable prints one aligned row per crash without report bodies.
+// Titles longer than width are truncated (unless width is 0).
+func printTable(w io.Writer, logs []*parsedLog, multiFile bool, width int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "#\tTYPE\tTITLE\tCORRUPTED\tSUPPRESSED\n"
+	if multiFile {
+		header = "FILE\t" + header
+	}
+	fmt.Fprint(tw, header)
+	idx := 0
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			idx++
+			if multiFile {
+				fmt.Fprintf(tw, "%v\t", parsed.source)
+			}
+			fmt.Fprintf(tw, "%d\t%v\t%v\t%v\t%v\n", idx, rep.Type, truncateTitle(rep.Title, width),
+				rep.Corrupted, rep.Suppressed)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		tool.Fail(err)
+	}
+}
+
+// truncateTitle shortens title to at most width runes, marking the cut with "...".
+func truncateTitle(title string, width int) string {
+	runes := []rune(title)
+	if width <= 0 || len(runes) <= width {
+		return title
+	}
+	const ellipsis = "..."
+	if width <= len(ellipsis) {
+		return string(runes[:width])
+	}
+	return string(runes[:width-len(ellipsis)]) + ellipsis
+}
+
+// emitCSV writes a header row and one row per crash.
+func emitCSV(w io.Writer, logs []*parsedLog, multiFile bool) {
+	cw := csv.NewWriter(w)
+	header := []string{"title", "type", "frame", "start_pos", "end_pos", "suppressed", "corrupted"}
+	if multiFile {
+		header = append([]string{"source_file"}, header...)
+	}
+	cw.Write(header)
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			row := []string{
+				rep.Title,
+				rep.Type,
+				rep.Frame,
+				strconv.Itoa(rep.StartPos),
+				strconv.Itoa(rep.EndPos),
+				strconv.FormatBool(rep.Suppressed),
+				strconv.FormatBool(rep.Corrupted),
+			}
+			if multiFile {
+				row = append([]string{parsed.source}, row...)
+			}
+			cw.Write(row)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		tool.Fail(err)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		width int
+		want  string
+	}{
+		{"KASAN: use-after-free Read in foo", 0, "KASAN: use-after-free Read in foo"},
+		{"KASAN: use-after-free Read in foo", 100, "KASAN: use-after-free Read in foo"},
+		{"KASAN: use-after-free Read in foo", 12, "KASAN: us..."},
+		{"KASAN: use-after-free Read in foo", 2, "KA"},
+		{"WARNING in ünïcode", 12, "WARNING i..."},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, truncateTitle(test.title, test.width), "title %q width %v",
+			test.title, test.width)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	// /dev/null is a character device, but not a terminal.
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	for _, w := range []io.Writer{devNull, new(bytes.Buffer)} {
+		color, err := colorEnabled("auto", w)
+		assert.NoError(t, err)
+		assert.False(t, color)
+	}
+	color, err := colorEnabled("always", new(bytes.Buffer))
+	assert.NoError(t, err)
+	assert.True(t, color)
+	_, err = colorEnabled("sometimes", new(bytes.Buffer))
+	assert.Error(t, err)
+}
+
+func TestPrintTable(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Type: "WARNING"},
+			{Title: "KASAN: slab-out-of-bounds Read in bar", Type: "KASAN-READ", Corrupted: true},
+		}},
+		{source: "b.log", crashes: []*logparser.Report{
+			{Title: "lost connection to test machine", Type: "LOST_CONNECTION", Suppressed: true},
+		}},
+	}
+	buf := new(bytes.Buffer)
+	printTable(buf, logs, false, 20)
+	assert.Equal(t, `#  TYPE             TITLE                 CORRUPTED  SUPPRESSED
+1  WARNING          WARNING in foo        false      false
+2  KASAN-READ       KASAN: slab-out-o...  true       false
+3  LOST_CONNECTION  lost connection t...  false      true
+`, buf.String())
+
+	buf.Reset()
+	printTable(buf, logs[1:], true, 0)
+	assert.Equal(t, `FILE   #  TYPE             TITLE                            CORRUPTED  SUPPRESSED
+b.log  1  LOST_CONNECTION  lost connection to test machine  false      true
+`, buf.String())
+}
+
+func TestEmitCSV(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{
+			{Title: "WARNING in foo, bar", Type: "WARNING", Frame: "foo", StartPos: 10, EndPos: 20},
+			{Title: `KASAN: "quoted"`, Type: "KASAN-READ", Corrupted: true},
+		}},
+	}
+	buf := new(bytes.Buffer)
+	emitCSV(buf, logs, false)
+	assert.Equal(t, `title,type,frame,start_pos,end_pos,suppressed,corrupted
+"WARNING in foo, bar",WARNING,foo,10,20,false,false
+"KASAN: ""quoted""",KASAN-READ,,0,0,false,true
+`, buf.String())
+
+	buf.Reset()
+	emitCSV(buf, logs[:1], true)
+	assert.Equal(t, `source_file,title,type,frame,start_pos,end_pos,suppressed,corrupted
+a.log,"WARNING in foo, bar",WARNING,foo,10,20,false,false
+a.log,"KASAN: ""quoted""",KASAN-READ,,0,0,false,true
+`, buf.String())
+}
+
+func TestEmitJSONEnvelope(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{{Title: "WARNING in foo", Type: "WARNING"}}},
+	}
+	for _, multiFile := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		emitJSONEnvelope(buf, logs, &emitOptions{
+			format:    formatJSON,
+			multiFile: multiFile,
+			target:    "linux/amd64",
+			total:     3,
+		})
+		var envelope jsonEnvelope
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &envelope))
+		assert.Equal(t, logparser.SchemaVersion, envelope.SchemaVersion)
+		assert.Equal(t, "linux/amd64", envelope.Target)
+		assert.NotEmpty(t, envelope.ToolVersion)
+		assert.False(t, envelope.ParsedAt.IsZero())
+		assert.Equal(t, logs[0].crashes, envelope.Crashes)
+		assert.Equal(t, 3, envelope.Total)
+		if multiFile {
+			assert.Empty(t, envelope.SourceFile)
+		} else {
+			assert.Equal(t, "a.log", envelope.SourceFile)
+		}
+	}
+}
+
+func TestEmitJSONEnvelopeEmptyLogs(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{{Title: "WARNING in foo", Type: "WARNING"}}},
+		{source: "b.log"},
+		{source: "c.log", suppressed: true},
+	}
+	defer func() { *flagEmitEmpty = false }()
+	for _, emitEmpty := range []bool{false, true} {
+		*flagEmitEmpty = emitEmpty
+		buf := new(bytes.Buffer)
+		emitJSONEnvelope(buf, logs, &emitOptions{format: formatJSON, multiFile: true})
+		if !emitEmpty {
+			assert.NotContains(t, buf.String(), "empty_logs")
+			continue
+		}
+		assert.Contains(t, buf.String(), `"source_file": "b.log",`+"\n"+
+			`      "suppressed": false,`+"\n"+`      "crashes": []`)
+		var envelope jsonEnvelope
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &envelope))
+		assert.Equal(t, logs[0].crashes, envelope.Crashes)
+		assert.Equal(t, []*emptyLog{
+			{SourceFile: "b.log", Crashes: []*logparser.Report{}},
+			{SourceFile: "c.log", Suppressed: true, Crashes: []*logparser.Report{}},
+		}, envelope.EmptyLogs)
+	}
+}
+
+func TestEmitJSONEnvelopeErrors(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{{Title: "WARNING in foo", Type: "WARNING"}}},
+	}
+	buf := new(bytes.Buffer)
+	emitJSONEnvelope(buf, logs, &emitOptions{format: formatJSON, multiFile: true})
+	assert.NotContains(t, buf.String(), `"errors"`)
+	errs := []*logError{{SourceFile: "b.log", Error: "failed to read log file: permission denied"}}
+	buf.Reset()
+	emitJSONEnvelope(buf, logs, &emitOptions{format: formatJSON, multiFile: true, errors: errs})
+	var envelope jsonEnvelope
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &envelope))
+	assert.Equal(t, logs[0].crashes, envelope.Crashes)
+	assert.Equal(t, errs, envelope.Errors)
+	assert.Contains(t, buf.String(), `"source_file": "b.log",`+"\n"+
+		`      "error": "failed to read log file: permission denied"`)
+}
+
+func TestEmitJSONL(t *testing.T) {
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Type: "WARNING", Report: "multi\nline\n"},
+			{Title: "KASAN: use-after-free Read in bar", Type: "KASAN-READ"},
+		}},
+		{},
+		{crashes: []*logparser.Report{{Title: "lost connection to test machine", Type: "LOST_CONNECTION"}}},
+	}
+	buf := new(bytes.Buffer)
+	emitJSONL(buf, logs)
+	out := buf.String()
+	assert.True(t, strings.HasSuffix(out, "}\n"), out)
+	assert.False(t, strings.HasPrefix(out, "["), out)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	assert.Len(t, lines, 3)
+	var titles []string
+	for _, line := range lines {
+		var crash logparser.Report
+		assert.NoError(t, json.Unmarshal([]byte(line), &crash), line)
+		titles = append(titles, crash.Title)
+	}
+	assert.Equal(t, []string{"WARNING in foo", "KASAN: use-after-free Read in bar",
+		"lost connection to test machine"}, titles)
+	buf.Reset()
+	emitJSONL(buf, []*parsedLog{{}})
+	assert.Empty(t, buf.String())
+}
+
+func TestJSONCompact(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", crashes: []*logparser.Report{{Title: "WARNING in foo"}, {Title: "KASAN: use-after-free in bar"}}},
+	}
+	indented := new(bytes.Buffer)
+	emitJSON(indented, logs)
+	*flagJSONCompact = true
+	defer func() { *flagJSONCompact = false }()
+	compact := new(bytes.Buffer)
+	emitJSON(compact, logs)
+	assert.Equal(t, 1, strings.Count(compact.String(), "\n"))
+	assert.True(t, strings.HasPrefix(compact.String(), `[{"title":"WARNING in foo",`), compact.String())
+	var want, got []*logparser.Report
+	assert.NoError(t, json.Unmarshal(indented.Bytes(), &want))
+	assert.NoError(t, json.Unmarshal(compact.Bytes(), &got))
+	assert.Equal(t, want, got)
+	assert.Less(t, compact.Len(), indented.Len())
+}
+
+func TestIndent(t *testing.T) {
+	logs := []*parsedLog{{crashes: []*logparser.Report{{Title: "WARNING in foo"}}}}
+	defer func() { *flagIndent = 2 }()
+	for indent, prefix := range map[int]string{
+		0: `[{"title"`,
+		2: "[\n  {\n    \"title\"",
+		4: "[\n    {\n        \"title\"",
+	} {
+		*flagIndent = indent
+		buf := new(bytes.Buffer)
+		emitJSON(buf, logs)
+		assert.True(t, strings.HasPrefix(buf.String(), prefix), "%v: %q", indent, buf.String())
+	}
+}
+
+func TestNewRawLog(t *testing.T) {
+	assert.Equal(t, &rawLog{SourceFile: "a.log", Encoding: "text", Data: "BUG: foo\n"},
+		newRawLog("a.log", []byte("BUG: foo\n")))
+	assert.Equal(t, &rawLog{SourceFile: "", Encoding: "base64", Data: "QlVHOiD/"},
+		newRawLog("", []byte("BUG: \xff")))
+}
+
+func TestPrintHumanQuiet(t *testing.T) {
+	logs := []*parsedLog{
+		{source: "a.log", suppressed: true},
+		{source: "b.log", crashes: []*logparser.Report{{Title: "WARNING in foo", Type: "WARNING", Report: "body\n"}}},
+	}
+	buf := new(bytes.Buffer)
+	printHuman(buf, logs[:1], false, false)
+	assert.Equal(t, "no crash reports found in log\nnote: log matched suppression patterns for this target\n",
+		buf.String())
+	buf.Reset()
+	printHuman(buf, logs[:1], false, true)
+	assert.Equal(t, "", buf.String())
+	buf.Reset()
+	printHuman(buf, logs, true, true)
+	assert.True(t, strings.HasPrefix(buf.String(), "=== b.log ===\n\nCrash #1\n"), buf.String())
+}
+
+func TestPrintCrashesMaintainers(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printCrashes(buf, []*logparser.Report{{
+		Title:       "WARNING in bar",
+		GuiltyFile:  "kernel/foo.c",
+		GuiltyLine:  "kernel/foo.c:1",
+		Maintainers: []string{"foo@example.com", "bar@example.com"},
+		Report:      "body\n",
+	}})
+	assert.Contains(t, buf.String(), "\nGuilty: kernel/foo.c:1\n")
+	assert.Contains(t, buf.String(), "\nMaintainers: foo@example.com, bar@example.com\n")
+	buf.Reset()
+	printCrashes(buf, []*logparser.Report{{Title: "WARNING in bar", Report: "body\n"}})
+	assert.NotContains(t, buf.String(), "Maintainers:")
+}
+
+func TestPrintCrashesNoBody(t *testing.T) {
+	*flagNoBody = true
+	defer func() { *flagNoBody = false }()
+	buf := new(bytes.Buffer)
+	printCrashes(buf, []*logparser.Report{{Title: "WARNING in bar", ContextBefore: "before\n", ContextAfter: "after\n"}})
+	assert.Contains(t, buf.String(), "\n\nbefore\n(body omitted)\nafter\n")
+}
+
+func TestPrintReports(t *testing.T) {
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{{Report: "first\nbody\n"}, {Report: "no newline"}}},
+		{},
+		{crashes: []*logparser.Report{{Report: ""}}},
+	}
+	buf := new(bytes.Buffer)
+	printReports(buf, logs, "---")
+	assert.Equal(t, "first\nbody\n---\nno newline\n---\n", buf.String())
+	buf.Reset()
+	printReports(buf, logs[:1], "")
+	assert.Equal(t, "first\nbody\n\nno newline\n", buf.String())
+	buf.Reset()
+	printReports(buf, nil, "---")
+	assert.Empty(t, buf.String())
+}
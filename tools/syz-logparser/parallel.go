@@ -0,0 +1,43 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+)
+
+// parseResult is the outcome of parsing a single input log.
+type parseResult struct {
+	path   string
+	parsed *parsedLog
+	err    error
+}
+
+// parseLogs parses the logs using up to jobs concurrent workers.
+// Results are returned in the order of paths regardless of the order in which parsing finishes.
+// The parser is shared by all workers: report.Reporter is safe for concurrent use
+// (syz-manager uses a single reporter for all VMs).
+// Every parsed log is reported to prog (if not nil).
+func parseLogs(parser *logParser, paths []string, jobs int, prog *progress) []parseResult {
+	results := make([]parseResult, len(paths))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(paths); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				parsed, err := parser.parseLog(paths[idx])
+				results[idx] = parseResult{paths[idx], parsed, err}
+				prog.add()
+			}
+		}()
+	}
+	for idx := range paths {
+		indices <- idx
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogs(t *testing.T) {
+	parser := newTestParser(t)
+	const bootLine = "[    0.000000] booting\n"
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("log%v", i))
+		// Every log has a different number of lines before the crash.
+		log := strings.Repeat(bootLine, i) + "WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n"
+		if i%5 == 0 {
+			path += ".missing"
+		} else {
+			assert.NoError(t, os.WriteFile(path, []byte(log), 0644))
+		}
+		paths = append(paths, path)
+	}
+	for _, jobs := range []int{1, 3, 100} {
+		results := parseLogs(parser, paths, jobs, nil)
+		assert.Len(t, results, len(paths))
+		for i, res := range results {
+			assert.Equal(t, paths[i], res.path)
+			if i%5 == 0 {
+				assert.Error(t, res.err)
+				continue
+			}
+			assert.NoError(t, res.err)
+			if assert.Len(t, res.parsed.crashes, 1) {
+				assert.Equal(t, i*len(bootLine), res.parsed.crashes[0].StartPos)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between -progress updates.
+var progressInterval = time.Second
+
+// progress reports the number of parsed logs. On a terminal the line is updated
+// in place, otherwise a new line is printed on every update.
+// All methods are safe for concurrent use and are no-ops on a nil progress.
+type progress struct {
+	w        io.Writer
+	terminal bool
+	total    int
+	mu       sync.Mutex
+	done     int
+	last     time.Time
+}
+
+func newProgress(w io.Writer, total int) *progress {
+	return &progress{
+		w:        w,
+		terminal: isTerminal(w),
+		total:    total,
+		last:     time.Now(),
+	}
+}
+
+// add records that one more log was parsed. The last update is always printed.
+func (p *progress) add() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	now := time.Now()
+	if p.done != p.total && now.Sub(p.last) < progressInterval {
+		return
+	}
+	p.last = now
+	if p.terminal {
+		fmt.Fprintf(p.w, "\rparsed %v/%v files", p.done, p.total)
+		if p.done == p.total {
+			fmt.Fprintf(p.w, "\n")
+		}
+	} else {
+		fmt.Fprintf(p.w, "parsed %v/%v files\n", p.done, p.total)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	defer func(interval time.Duration) { progressInterval = interval }(progressInterval)
+	buf := new(bytes.Buffer)
+	progressInterval = 0
+	p := newProgress(buf, 3)
+	for i := 0; i < 3; i++ {
+		p.add()
+	}
+	assert.Equal(t, "parsed 1/3 files\nparsed 2/3 files\nparsed 3/3 files\n", buf.String())
+
+	// Only the final update is printed if the logs are parsed quickly.
+	buf.Reset()
+	progressInterval = time.Hour
+	p = newProgress(buf, 3)
+	for i := 0; i < 3; i++ {
+		p.add()
+	}
+	assert.Equal(t, "parsed 3/3 files\n", buf.String())
+
+	var nilProgress *progress
+	nilProgress.add()
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+// The subset of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html)
+// that is needed to report crashes.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string             `json:"ruleId"`
+	Level               string             `json:"level"`
+	Message             sarifMessage       `json:"message"`
+	Locations           []sarifLocation    `json:"locations"`
+	RelatedLocations    []sarifLocation    `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+	Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+// sarifRegion is the part of the log with the crash: 1-based lines and 0-based bytes.
+type sarifRegion struct {
+	StartLine  int `json:"startLine"`
+	EndLine    int `json:"endLine"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind string `json:"kind"`
+}
+
+func emitSARIF(w io.Writer, logs []*parsedLog) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "syz-logparser",
+			InformationURI: "https://github.com/google/syzkaller",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	rules := make(map[string]bool)
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			if !rules[rep.Type] {
+				rules[rep.Type] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               rep.Type,
+					ShortDescription: sarifMessage{Text: "kernel crash of type " + rep.Type},
+				})
+			}
+			run.Results = append(run.Results, sarifCrash(rep))
+		}
+	}
+	enc := json.NewEncoder(w)
+	indentJSON(enc)
+	if err := enc.Encode(&sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}); err != nil {
+		tool.Fail(err)
+	}
+}
+
+func sarifCrash(rep *logparser.Report) sarifResult {
+	res := sarifResult{
+		RuleID:  rep.Type,
+		Level:   "error",
+		Message: sarifMessage{Text: rep.Title},
+	}
+	if rep.Corrupted {
+		res.Level = "warning"
+	}
+	if rep.Suppressed {
+		res.Suppressions = []sarifSuppression{{Kind: "external"}}
+	}
+	if rep.Fingerprint != "" {
+		res.PartialFingerprints = map[string]string{"syzFingerprint/v1": rep.Fingerprint}
+	}
+	// The crash is located in the log (unless it was read from stdin) and in the crashing
+	// function (or the crash itself). The guilty file is known only if the report was symbolized.
+	loc := sarifLocation{LogicalLocations: []sarifLogicalLocation{{Name: rep.Title}}}
+	if rep.Frame != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{Name: rep.Frame, Kind: "function"}}
+	}
+	if rep.SourceFile != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: rep.SourceFile},
+			Region: &sarifRegion{
+				StartLine:  rep.LineStart,
+				EndLine:    max(rep.LineEnd, rep.LineStart),
+				ByteOffset: rep.StartPos,
+				ByteLength: rep.EndPos - rep.StartPos,
+			},
+		}
+	}
+	res.Locations = []sarifLocation{loc}
+	if rep.GuiltyFile != "" {
+		res.RelatedLocations = []sarifLocation{{
+			PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: rep.GuiltyFile}},
+			Message:          &sarifMessage{Text: "guilty file"},
+		}}
+	}
+	return res
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitSARIF(t *testing.T) {
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{
+			{Title: "KASAN: use-after-free Read in foo", Type: "KASAN-READ", Frame: "foo",
+				GuiltyFile: "mm/foo.c", Fingerprint: "abc", SourceFile: "console.log",
+				StartPos: 100, EndPos: 250, LineStart: 3, LineEnd: 7},
+			{Title: "KASAN: use-after-free Read in bar", Type: "KASAN-READ", Frame: "bar", Corrupted: true},
+		}},
+		{crashes: []*logparser.Report{
+			{Title: "lost connection to test machine", Type: "LOST_CONNECTION", Suppressed: true},
+		}},
+	}
+	buf := new(bytes.Buffer)
+	emitSARIF(buf, logs)
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, []sarifRule{
+		{ID: "KASAN-READ", ShortDescription: sarifMessage{Text: "kernel crash of type KASAN-READ"}},
+		{ID: "LOST_CONNECTION", ShortDescription: sarifMessage{Text: "kernel crash of type LOST_CONNECTION"}},
+	}, run.Tool.Driver.Rules)
+	assert.Equal(t, []sarifResult{
+		{
+			RuleID:  "KASAN-READ",
+			Level:   "error",
+			Message: sarifMessage{Text: "KASAN: use-after-free Read in foo"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "console.log"},
+					Region:           &sarifRegion{StartLine: 3, EndLine: 7, ByteOffset: 100, ByteLength: 150},
+				},
+				LogicalLocations: []sarifLogicalLocation{{Name: "foo", Kind: "function"}},
+			}},
+			RelatedLocations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "mm/foo.c"}},
+				Message:          &sarifMessage{Text: "guilty file"},
+			}},
+			PartialFingerprints: map[string]string{"syzFingerprint/v1": "abc"},
+		},
+		{
+			RuleID:  "KASAN-READ",
+			Level:   "warning",
+			Message: sarifMessage{Text: "KASAN: use-after-free Read in bar"},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{
+				{Name: "bar", Kind: "function"},
+			}}},
+		},
+		{
+			RuleID:  "LOST_CONNECTION",
+			Level:   "error",
+			Message: sarifMessage{Text: "lost connection to test machine"},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{
+				{Name: "lost connection to test machine"},
+			}}},
+			Suppressions: []sarifSuppression{{Kind: "external"}},
+		},
+	}, run.Results)
+}
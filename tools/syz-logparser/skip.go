@@ -0,0 +1,29 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// describeSkip explains where parsing of data resumes after rep (see -show-skip).
+// The reporter continues at SkipPos, so the bytes between EndPos and SkipPos
+// are never looked at again, while a SkipPos inside the report means that the rest
+// of the report is parsed again (and may produce further crashes).
+func describeSkip(data []byte, rep *logparser.Report) string {
+	prefix := fmt.Sprintf("%q [%d, %d): parsing resumes at %d", rep.Title, rep.StartPos, rep.EndPos, rep.SkipPos)
+	switch {
+	case rep.SkipPos < 0 || rep.SkipPos > len(data) || rep.EndPos < 0 || rep.EndPos > len(data):
+		return prefix + ", the positions are out of the log"
+	case rep.SkipPos > rep.EndPos:
+		return fmt.Sprintf("%v, skipped %d bytes: %q", prefix, rep.SkipPos-rep.EndPos, data[rep.EndPos:rep.SkipPos])
+	case rep.SkipPos == rep.EndPos:
+		return prefix + ", nothing is skipped"
+	default:
+		return fmt.Sprintf("%v, nothing is skipped, the last %d bytes of the report are parsed again",
+			prefix, rep.EndPos-rep.SkipPos)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeSkip(t *testing.T) {
+	data := []byte("BUG: foo\nbody\nnoise\nBUG: bar\n")
+	rep := &logparser.Report{Title: "BUG: foo", StartPos: 0, EndPos: 14, SkipPos: 20}
+	assert.Equal(t, `"BUG: foo" [0, 14): parsing resumes at 20, skipped 6 bytes: "noise\n"`,
+		describeSkip(data, rep))
+	rep.SkipPos = 14
+	assert.Equal(t, `"BUG: foo" [0, 14): parsing resumes at 14, nothing is skipped`, describeSkip(data, rep))
+	rep.SkipPos = 9
+	assert.Equal(t, `"BUG: foo" [0, 14): parsing resumes at 9, nothing is skipped,`+
+		` the last 5 bytes of the report are parsed again`, describeSkip(data, rep))
+	rep.SkipPos = 100
+	assert.Equal(t, `"BUG: foo" [0, 14): parsing resumes at 100, the positions are out of the log`,
+		describeSkip(data, rep))
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// sortKeys maps -sort values to the crash field crashes are ordered by.
+// Crashes with equal keys are ordered by position in the log.
+var sortKeys = map[string]func(rep *logparser.Report) string{
+	"pos":   func(rep *logparser.Report) string { return "" },
+	"title": func(rep *logparser.Report) string { return rep.Title },
+	"type":  func(rep *logparser.Report) string { return rep.Type },
+}
+
+func checkSortKey(key string) error {
+	if key == "" || sortKeys[key] != nil {
+		return nil
+	}
+	var known []string
+	for name := range sortKeys {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return fmt.Errorf("unknown -sort key %q (valid keys: %v)", key, strings.Join(known, ", "))
+}
+
+// sortCrashes orders crashes of a log by the given -sort key in place.
+func sortCrashes(crashes []*logparser.Report, key string) {
+	field := sortKeys[key]
+	sort.SliceStable(crashes, func(i, j int) bool {
+		if ki, kj := field(crashes[i]), field(crashes[j]); ki != kj {
+			return ki < kj
+		}
+		return crashes[i].StartPos < crashes[j].StartPos
+	})
+}
+
+// reverseCrashes reverses the order of the logs and of the crashes of every log in place,
+// so that the crashes are output from the last one to the first one.
+func reverseCrashes(logs []*parsedLog) {
+	slices.Reverse(logs)
+	for _, parsed := range logs {
+		slices.Reverse(parsed.crashes)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortCrashes(t *testing.T) {
+	a := &logparser.Report{Title: "b", Type: "WARNING", StartPos: 30}
+	b := &logparser.Report{Title: "a", Type: "WARNING", StartPos: 20}
+	c := &logparser.Report{Title: "b", Type: "KASAN-READ", StartPos: 10}
+	sorted := func(key string) []*logparser.Report {
+		crashes := []*logparser.Report{a, b, c}
+		sortCrashes(crashes, key)
+		return crashes
+	}
+	assert.Equal(t, []*logparser.Report{c, b, a}, sorted("pos"))
+	assert.Equal(t, []*logparser.Report{b, c, a}, sorted("title"))
+	assert.Equal(t, []*logparser.Report{c, b, a}, sorted("type"))
+
+	assert.NoError(t, checkSortKey(""))
+	assert.NoError(t, checkSortKey("title"))
+	assert.ErrorContains(t, checkSortKey("size"), `unknown -sort key "size" (valid keys: pos, title, type)`)
+}
+
+func TestReverseCrashes(t *testing.T) {
+	a := &logparser.Report{Title: "a"}
+	b := &logparser.Report{Title: "b"}
+	c := &logparser.Report{Title: "c"}
+	logs := []*parsedLog{
+		{source: "1.log", crashes: []*logparser.Report{a, b}},
+		{source: "2.log"},
+		{source: "3.log", crashes: []*logparser.Report{c}},
+	}
+	reverseCrashes(logs)
+	assert.Equal(t, []*parsedLog{
+		{source: "3.log", crashes: []*logparser.Report{c}},
+		{source: "2.log"},
+		{source: "1.log", crashes: []*logparser.Report{b, a}},
+	}, logs)
+	// -reverse -limit 1 is the last crash.
+	last := limitCrashes(logs, 1)
+	assert.Equal(t, []*logparser.Report{c}, last[0].crashes)
+}
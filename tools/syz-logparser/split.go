@@ -0,0 +1,92 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// maxFileTitle is the maximum length of the title part of -split-dir file names.
+const maxFileTitle = 80
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// sanitizeTitle converts the crash title into a safe file name part.
+func sanitizeTitle(title string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(title, "_"), "_.")
+	if len(name) > maxFileTitle {
+		name = strings.TrimRight(name[:maxFileTitle], "_.")
+	}
+	if name == "" {
+		name = "untitled"
+	}
+	return name
+}
+
+// splitCrashes writes the report body of every crash to a separate file in dir
+// and stores the file paths in OutputFile. Existing files are not overwritten,
+// a numeric suffix is added to the file name instead.
+func splitCrashes(logs []*parsedLog, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create -split-dir: %w", err)
+	}
+	idx := 0
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			idx++
+			path, err := writeCrashFile(dir, fmt.Sprintf("crash-%d-%s", idx, sanitizeTitle(rep.Title)), rep)
+			if err != nil {
+				return err
+			}
+			rep.OutputFile = path
+		}
+	}
+	return nil
+}
+
+func writeCrashFile(dir, name string, rep *logparser.Report) (string, error) {
+	for suffix := 1; ; suffix++ {
+		path := filepath.Join(dir, name+".txt")
+		if suffix > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%v-%d.txt", name, suffix))
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create crash file: %w", err)
+		}
+		body := rep.Report
+		if body != "" && !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		_, err = io.WriteString(f, body)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write crash file: %w", err)
+		}
+		return path, nil
+	}
+}
+
+// printOutputFiles prints the -split-dir files of all crashes, one per line.
+func printOutputFiles(w io.Writer, logs []*parsedLog) {
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			fmt.Fprintln(w, rep.OutputFile)
+		}
+	}
+}
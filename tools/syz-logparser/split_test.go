@@ -0,0 +1,48 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeTitle(t *testing.T) {
+	for title, want := range map[string]string{
+		"KASAN: use-after-free Read in foo": "KASAN_use-after-free_Read_in_foo",
+		"WARNING in foo/bar (2)":            "WARNING_in_foo_bar_2",
+		"../../etc/passwd":                  "etc_passwd",
+		"":                                  "untitled",
+		"???":                               "untitled",
+		strings.Repeat("a", 100):            strings.Repeat("a", maxFileTitle),
+	} {
+		assert.Equal(t, want, sanitizeTitle(title), title)
+	}
+}
+
+func TestSplitCrashes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crashes")
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{{Title: "WARNING in foo", Report: "first\n"}}},
+		{crashes: []*logparser.Report{{Title: "WARNING in foo", Report: "second"}}},
+	}
+	assert.NoError(t, splitCrashes(logs, dir))
+	assert.Equal(t, filepath.Join(dir, "crash-1-WARNING_in_foo.txt"), logs[0].crashes[0].OutputFile)
+	assert.Equal(t, filepath.Join(dir, "crash-2-WARNING_in_foo.txt"), logs[1].crashes[0].OutputFile)
+	data, err := os.ReadFile(logs[1].crashes[0].OutputFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(data))
+
+	// Files of a previous run are not overwritten.
+	assert.NoError(t, splitCrashes(logs[:1], dir))
+	assert.Equal(t, filepath.Join(dir, "crash-1-WARNING_in_foo-2.txt"), logs[0].crashes[0].OutputFile)
+	data, err = os.ReadFile(filepath.Join(dir, "crash-1-WARNING_in_foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", string(data))
+}
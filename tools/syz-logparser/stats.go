@@ -0,0 +1,110 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+// crashStats summarizes crashes across all parsed logs.
+type crashStats struct {
+	ByType  map[string]int `json:"by_type"`
+	ByTitle map[string]int `json:"by_title"`
+	// TypeHistogram is ByType sorted by decreasing count with the share of every type.
+	TypeHistogram []typeCount `json:"type_histogram"`
+	Total         int         `json:"total"`
+	Suppressed    int         `json:"suppressed"`
+	Corrupted     int         `json:"corrupted"`
+}
+
+type typeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+	// Percent is the percentage of all crashes.
+	Percent float64 `json:"percent"`
+}
+
+func collectStats(logs []*parsedLog) *crashStats {
+	stats := &crashStats{
+		ByType:  make(map[string]int),
+		ByTitle: make(map[string]int),
+	}
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			stats.Total++
+			stats.ByType[rep.Type]++
+			stats.ByTitle[rep.Title]++
+			if rep.Suppressed {
+				stats.Suppressed++
+			}
+			if rep.Corrupted {
+				stats.Corrupted++
+			}
+		}
+	}
+	stats.TypeHistogram = []typeCount{}
+	for _, typ := range sortedKeys(stats.ByType) {
+		count := stats.ByType[typ]
+		stats.TypeHistogram = append(stats.TypeHistogram, typeCount{
+			Type:    typ,
+			Count:   count,
+			Percent: float64(count) * 100 / float64(stats.Total),
+		})
+	}
+	return stats
+}
+
+func emitStats(w io.Writer, stats *crashStats, format string) {
+	if format == formatJSON || format == formatJSONL {
+		enc := json.NewEncoder(w)
+		if format == formatJSON {
+			indentJSON(enc)
+		}
+		if err := enc.Encode(stats); err != nil {
+			tool.Fail(err)
+		}
+		return
+	}
+	fmt.Fprintf(w, "Total: %v\n", stats.Total)
+	fmt.Fprintf(w, "Suppressed: %v\n", stats.Suppressed)
+	fmt.Fprintf(w, "Corrupted: %v\n", stats.Corrupted)
+	if len(stats.TypeHistogram) != 0 {
+		fmt.Fprintf(w, "\nBy type:\n")
+		for _, tc := range stats.TypeHistogram {
+			fmt.Fprintf(w, "%6d  %5.1f%%  %v\n", tc.Count, tc.Percent, tc.Type)
+		}
+	}
+	printCounts(w, "By title", stats.ByTitle)
+}
+
+// printCounts prints the counts sorted by decreasing count, then by key.
+func printCounts(w io.Writer, header string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%v:\n", header)
+	for _, key := range sortedKeys(counts) {
+		fmt.Fprintf(w, "%6d  %v\n", counts[key], key)
+	}
+}
+
+// sortedKeys returns the keys of counts sorted by decreasing count, then by key.
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
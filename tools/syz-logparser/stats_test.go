@@ -0,0 +1,51 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStats(t *testing.T) {
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Type: "WARNING"},
+			{Title: "WARNING in foo", Type: "WARNING", Suppressed: true},
+		}},
+		{},
+		{crashes: []*logparser.Report{
+			{Title: "KASAN: use-after-free Read in bar", Type: "KASAN-USE-AFTER-FREE-READ", Corrupted: true},
+		}},
+	}
+	assert.Equal(t, &crashStats{
+		ByType: map[string]int{
+			"WARNING":                   2,
+			"KASAN-USE-AFTER-FREE-READ": 1,
+		},
+		ByTitle: map[string]int{
+			"WARNING in foo":                    2,
+			"KASAN: use-after-free Read in bar": 1,
+		},
+		TypeHistogram: []typeCount{
+			{Type: "WARNING", Count: 2, Percent: 200.0 / 3},
+			{Type: "KASAN-USE-AFTER-FREE-READ", Count: 1, Percent: 100.0 / 3},
+		},
+		Total:      3,
+		Suppressed: 1,
+		Corrupted:  1,
+	}, collectStats(logs))
+
+	buf := new(bytes.Buffer)
+	emitStats(buf, collectStats(logs), formatHuman)
+	assert.Contains(t, buf.String(), `
+By type:
+     2   66.7%  WARNING
+     1   33.3%  KASAN-USE-AFTER-FREE-READ
+`)
+	assert.Empty(t, collectStats(nil).TypeHistogram)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// autoTarget picks a parser for the target detected in every log with -auto-target.
+// Logs of the same target share a parser, the parser for the -os/-arch flags is used
+// when detection fails. Parsers of detected targets use the config of the target from configs.
+type autoTarget struct {
+	opts     logparser.Options
+	configs  *configDir
+	fallback *logparser.Parser
+	// messages receives the detected targets (nil with -quiet).
+	messages io.Writer
+	mu       sync.Mutex
+	parsers  map[string]*logparser.Parser
+}
+
+func newAutoTarget(opts *logparser.Options, configs *configDir, fallback *logparser.Parser,
+	messages io.Writer) *autoTarget {
+	return &autoTarget{
+		opts:     *opts,
+		configs:  configs,
+		fallback: fallback,
+		messages: messages,
+		parsers:  map[string]*logparser.Parser{fallback.Target(): fallback},
+	}
+}
+
+// parserFor returns the parser for the target detected in data. name identifies the log in messages.
+func (a *autoTarget) parserFor(data []byte, name string) *logparser.Parser {
+	targetOS, arch := logparser.DetectTarget(data)
+	if targetOS == "" {
+		a.printf("%v: failed to detect the target, using %v\n", name, a.fallback.Target())
+		return a.fallback
+	}
+	target := targetOS + "/" + arch
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	parser := a.parsers[target]
+	if parser == nil {
+		opts := a.opts
+		opts.OS, opts.Arch, opts.VMArch = targetOS, arch, ""
+		opts.Config = a.configs.config(target, opts.Warnings)
+		var err error
+		parser, err = logparser.NewParser(&opts)
+		if err != nil {
+			// E.g. -vmlinux has a name that doesn't fit the detected OS.
+			a.printf("%v: detected target %v, but %v, using %v\n", name, target, err, a.fallback.Target())
+			return a.fallback
+		}
+		a.parsers[target] = parser
+	}
+	a.printf("%v: detected target %v\n", name, target)
+	return parser
+}
+
+func (a *autoTarget) printf(format string, args ...any) {
+	if a.messages != nil {
+		fmt.Fprintf(a.messages, format, args...)
+	}
+}
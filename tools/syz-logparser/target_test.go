@@ -0,0 +1,42 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoTarget(t *testing.T) {
+	parser := newTestParser(t)
+	messages := new(bytes.Buffer)
+	opts := &logparser.Options{OS: targets.Linux, Arch: targets.AMD64}
+	parser.autoTarget = newAutoTarget(opts, nil, parser.parser, messages)
+	dir := t.TempDir()
+	arm64 := filepath.Join(dir, "arm64.log")
+	arm64Log := "[    0.000000] Booting Linux on physical CPU 0x0000000000 [0x411fd070]\n" +
+		"[   10.000000] Unable to handle kernel NULL pointer dereference at virtual address 0000000000000000\n" +
+		"[   10.000000] pc : foo+0x1/0x2\n"
+	assert.NoError(t, os.WriteFile(arm64, []byte(arm64Log), 0644))
+	unknown := filepath.Join(dir, "unknown.log")
+	assert.NoError(t, os.WriteFile(unknown, []byte("[   10.000000] BUG: KASAN: use-after-free in foo+0x1/0x2\n"), 0644))
+	for i := 0; i < 2; i++ {
+		parsed, err := parser.parseLog(arm64)
+		assert.NoError(t, err)
+		assert.Len(t, parsed.crashes, 1)
+	}
+	assert.Equal(t, "linux/arm64", parser.autoTarget.parsers["linux/arm64"].Target())
+	parsed, err := parser.parseLog(unknown)
+	assert.NoError(t, err)
+	assert.Len(t, parsed.crashes, 1)
+	assert.Equal(t, arm64+": detected target linux/arm64\n"+
+		arm64+": detected target linux/arm64\n"+
+		unknown+": failed to detect the target, using linux/amd64\n", messages.String())
+}
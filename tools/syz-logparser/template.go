@@ -0,0 +1,61 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+// loadTemplate parses the template given with -template or -template-file.
+// It returns nil if neither flag is set.
+func loadTemplate() (*template.Template, error) {
+	text, name := *flagTemplate, "template"
+	if *flagTemplateFile != "" {
+		if *flagTemplate != "" {
+			return nil, fmt.Errorf("-template and -template-file are mutually exclusive")
+		}
+		data, err := os.ReadFile(*flagTemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		text, name = string(data), *flagTemplateFile
+	}
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("bad template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// executeTemplate executes tmpl for every crash, each output followed by a newline
+// (unless the template output already ends with one).
+func executeTemplate(w io.Writer, logs []*parsedLog, tmpl *template.Template) {
+	buf := new(strings.Builder)
+	for _, parsed := range logs {
+		for _, rep := range parsed.crashes {
+			buf.Reset()
+			if err := tmpl.Execute(buf, rep); err != nil {
+				tool.Failf("failed to execute template: %v", err)
+			}
+			out := buf.String()
+			if !strings.HasSuffix(out, "\n") {
+				out += "\n"
+			}
+			if _, err := io.WriteString(w, out); err != nil {
+				tool.Fail(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteTemplate(t *testing.T) {
+	*flagTemplate = `{{.Type}}: {{.Title}}{{if .AltTitles}} ({{join .AltTitles ", "}}){{end}}`
+	defer func() { *flagTemplate = "" }()
+	tmpl, err := loadTemplate()
+	assert.NoError(t, err)
+	logs := []*parsedLog{
+		{crashes: []*logparser.Report{
+			{Title: "WARNING in foo", Type: "WARNING", AltTitles: []string{"a", "b"}},
+		}},
+		{crashes: []*logparser.Report{
+			{Title: "KASAN: slab-out-of-bounds Read in bar", Type: "KASAN-READ"},
+		}},
+	}
+	buf := new(bytes.Buffer)
+	executeTemplate(buf, logs, tmpl)
+	assert.Equal(t, "WARNING: WARNING in foo (a, b)\nKASAN-READ: KASAN: slab-out-of-bounds Read in bar\n",
+		buf.String())
+
+	*flagTemplate = "{{.Title"
+	_, err = loadTemplate()
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// fileTiming is the time spent on a single log, printed with -timing.
+type fileTiming struct {
+	read time.Duration
+	logparser.Timing
+}
+
+func (t *fileTiming) add(other fileTiming) {
+	t.read += other.read
+	t.Parse += other.Parse
+	t.Symbolize += other.Symbolize
+	t.Serialize += other.Serialize
+}
+
+func (t fileTiming) String() string {
+	return fmt.Sprintf("read %v, parse %v, symbolize %v, serialize %v",
+		roundDuration(t.read), roundDuration(t.Parse), roundDuration(t.Symbolize), roundDuration(t.Serialize))
+}
+
+// printTotalTiming prints the sum of the timings of all parsed logs, the time spent writing
+// the output and the elapsed time of the whole run. With -jobs the sum can exceed the elapsed time.
+func printTotalTiming(w io.Writer, files int, total fileTiming, output, wall time.Duration) {
+	fmt.Fprintf(w, "timing: total (%v files): %v, output %v, wall %v\n",
+		files, total, roundDuration(output), roundDuration(wall))
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,30 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/logparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiming(t *testing.T) {
+	a := fileTiming{read: time.Millisecond, Timing: logparser.Timing{Parse: 1500 * time.Nanosecond}}
+	b := fileTiming{read: time.Millisecond, Timing: logparser.Timing{
+		Parse:     2 * time.Millisecond,
+		Symbolize: time.Second,
+		Serialize: 3 * time.Microsecond,
+	}}
+	assert.Equal(t, "read 1ms, parse 2µs, symbolize 0s, serialize 0s", a.String())
+	var total fileTiming
+	total.add(a)
+	total.add(b)
+	buf := new(bytes.Buffer)
+	printTotalTiming(buf, 2, total, 5*time.Millisecond, 2*time.Second)
+	assert.Equal(t, "timing: total (2 files): read 2ms, parse 2.002ms, symbolize 1s, serialize 3µs,"+
+		" output 5ms, wall 2s\n", buf.String())
+}
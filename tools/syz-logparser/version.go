@@ -0,0 +1,52 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// printVersion prints the syzkaller revision the tool was built from and the Go version.
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "syz-logparser\n")
+	fmt.Fprintf(w, "syzkaller revision: %v\n", toolVersion())
+	fmt.Fprintf(w, "go version: %v\n", runtime.Version())
+}
+
+func toolVersion() string {
+	info, _ := debug.ReadBuildInfo()
+	return syzkallerRevision(info)
+}
+
+// syzkallerRevision returns the revision set by the Makefile, or the VCS revision
+// recorded by the Go toolchain, or the main module version.
+func syzkallerRevision(info *debug.BuildInfo) string {
+	if prog.GitRevisionKnown() {
+		return prog.GitRevision
+	}
+	if info == nil {
+		return "unknown"
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return info.Main.Version
+	}
+	if modified {
+		revision += "+"
+	}
+	return revision
+}
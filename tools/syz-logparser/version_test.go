@@ -0,0 +1,21 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyzkallerRevision(t *testing.T) {
+	assert.Equal(t, "unknown", syzkallerRevision(nil))
+	info := &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}
+	assert.Equal(t, "(devel)", syzkallerRevision(info))
+	info.Settings = []debug.BuildSetting{{Key: "vcs.revision", Value: "0123abcd"}}
+	assert.Equal(t, "0123abcd", syzkallerRevision(info))
+	info.Settings = append(info.Settings, debug.BuildSetting{Key: "vcs.modified", Value: "true"})
+	assert.Equal(t, "0123abcd+", syzkallerRevision(info))
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/syzkaller/pkg/logparser"
+)
+
+// watchedFile is a file of the -watch-dir directory.
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	// changed is the time the file was last seen changing (its modification time
+	// if it was already there when it was first seen).
+	changed time.Time
+	// parsed is set once the file was parsed (or failed to parse) and was not moved away.
+	parsed bool
+}
+
+// watchState tracks the files of a watched directory by name.
+type watchState struct {
+	dir   string
+	files map[string]*watchedFile
+}
+
+func newWatchState(dir string) *watchState {
+	return &watchState{dir: dir, files: make(map[string]*watchedFile)}
+}
+
+// scan returns the names of the files that are ready to be parsed: they were not parsed yet
+// and have not changed for the quiet period. Hidden files and directories are ignored.
+// Files that changed after they were parsed are parsed again.
+func (st *watchState) scan(now time.Time, quiet time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(st.dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var ready []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed in the meantime.
+			continue
+		}
+		seen[name] = true
+		file := st.files[name]
+		if file == nil {
+			file = &watchedFile{size: info.Size(), modTime: info.ModTime(), changed: info.ModTime()}
+			st.files[name] = file
+		} else if file.size != info.Size() || !file.modTime.Equal(info.ModTime()) {
+			*file = watchedFile{size: info.Size(), modTime: info.ModTime(), changed: now}
+		}
+		if !file.parsed && now.Sub(file.changed) >= quiet {
+			ready = append(ready, name)
+		}
+	}
+	for name := range st.files {
+		if !seen[name] {
+			delete(st.files, name)
+		}
+	}
+	return ready, nil
+}
+
+// watch parses every file that appears in dir once it stops changing for the quiet period and
+// writes the crashes that pass filters as JSON lines (removed is updated with the number of
+// crashes dropped by each filter). Parsed files are moved to doneDir if it's set.
+// It returns the tally of the emitted crashes once SIGINT or SIGTERM is received.
+func (p *logParser) watch(w io.Writer, dir, doneDir string, quiet time.Duration, filters []crashFilter,
+	removed map[string]int) (*crashTally, error) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	ticker := time.NewTicker(followPollPeriod)
+	defer ticker.Stop()
+	enc := json.NewEncoder(w)
+	st := newWatchState(dir)
+	tally := newCrashTally(*flagFailOn)
+	for {
+		ready, err := st.scan(time.Now(), quiet)
+		if err != nil {
+			return tally, fmt.Errorf("failed to read -watch-dir: %w", err)
+		}
+		for _, name := range ready {
+			crashes := p.watchFile(st, name, doneDir, filters, removed)
+			for _, crash := range crashes {
+				if err := enc.Encode(crash); err != nil {
+					return tally, err
+				}
+			}
+			tally.add(crashes)
+		}
+		select {
+		case <-stop:
+			// Files that are still being written are parsed by the next run.
+			return tally, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchFile parses a ready file of the watched directory and moves it to doneDir.
+// Errors are printed to stderr; files that fail to parse or to move are left in place.
+func (p *logParser) watchFile(st *watchState, name, doneDir string, filters []crashFilter,
+	removed map[string]int) []*logparser.Report {
+	path := filepath.Join(st.dir, name)
+	st.files[name].parsed = true
+	parsed, err := p.parseLog(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", path, err)
+		return nil
+	}
+	processLog(p.parser, parsed, filters, removed)
+	if doneDir != "" {
+		if err := os.Rename(path, filepath.Join(doneDir, name)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to move parsed log: %v\n", err)
+		} else {
+			delete(st.files, name)
+		}
+	}
+	return parsed.crashes
+}
+
+// checkDoneDir checks that -done-dir is a directory other than -watch-dir.
+func checkDoneDir(watchDir, doneDir string) error {
+	info, err := os.Stat(doneDir)
+	if err != nil {
+		return fmt.Errorf("bad -done-dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("-done-dir %v is not a directory", doneDir)
+	}
+	if watchInfo, err := os.Stat(watchDir); err == nil && os.SameFile(info, watchInfo) {
+		return fmt.Errorf("-done-dir must differ from -watch-dir")
+	}
+	return nil
+}
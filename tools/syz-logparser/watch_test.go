@@ -0,0 +1,90 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchScan(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string, modTime time.Time) {
+		file := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		assert.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+	now := time.Now()
+	const quiet = 10 * time.Second
+	// Files that were already there long enough are ready right away.
+	write("old.log", "old", now.Add(-time.Hour))
+	write("new.log", "new", now)
+	write(".partial.log", "hidden", now.Add(-time.Hour))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	st := newWatchState(dir)
+	ready, err := st.scan(now, quiet)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old.log"}, ready)
+	st.files["old.log"].parsed = true
+
+	// A file that keeps growing is not ready until it stops changing for the quiet period.
+	write("new.log", "newer", now.Add(quiet/2))
+	ready, _ = st.scan(now.Add(quiet/2), quiet)
+	assert.Empty(t, ready)
+	ready, _ = st.scan(now.Add(quiet), quiet)
+	assert.Empty(t, ready)
+	ready, _ = st.scan(now.Add(quiet/2+quiet), quiet)
+	assert.Equal(t, []string{"new.log"}, ready)
+
+	// A parsed file that changes is parsed again, a removed one is forgotten.
+	write("old.log", "rewritten", now)
+	ready, _ = st.scan(now.Add(3*quiet), quiet)
+	assert.Equal(t, []string{"new.log"}, ready)
+	ready, _ = st.scan(now.Add(4*quiet), quiet)
+	assert.Equal(t, []string{"new.log", "old.log"}, ready)
+	assert.NoError(t, os.Remove(filepath.Join(dir, "new.log")))
+	st.scan(now.Add(4*quiet), quiet)
+	assert.NotContains(t, st.files, "new.log")
+
+	_, err = newWatchState(filepath.Join(dir, "missing")).scan(now, quiet)
+	assert.Error(t, err)
+}
+
+func TestWatchFile(t *testing.T) {
+	dir, doneDir := t.TempDir(), t.TempDir()
+	const log = "[   10.000000] ------------[ cut here ]------------\n" +
+		"[   10.000000] WARNING: CPU: 0 PID: 1 at kernel/foo.c:1 foo+0x1/0x2\n" +
+		"[   10.000000] Call Trace:\n" +
+		"[   10.000000]  bar+0x1/0x2\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "vm-0.log"), []byte(log), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "vmlinux"), make([]byte, 1000), 0644))
+	parser := newTestParser(t)
+	st := newWatchState(dir)
+	ready, err := st.scan(time.Now().Add(time.Minute), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vm-0.log", "vmlinux"}, ready)
+
+	removed := make(map[string]int)
+	crashes := parser.watchFile(st, "vm-0.log", doneDir, nil, removed)
+	assert.Len(t, crashes, 1)
+	assert.Equal(t, "WARNING in bar", crashes[0].Title)
+	assert.Equal(t, filepath.Join(dir, "vm-0.log"), crashes[0].SourceFile)
+	assert.FileExists(t, filepath.Join(doneDir, "vm-0.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "vm-0.log"))
+	assert.NotContains(t, st.files, "vm-0.log")
+	// Files that fail to parse stay in place and are not retried.
+	assert.Empty(t, parser.watchFile(st, "vmlinux", doneDir, nil, removed))
+	assert.FileExists(t, filepath.Join(dir, "vmlinux"))
+	ready, _ = st.scan(time.Now().Add(time.Minute), time.Second)
+	assert.Empty(t, ready)
+
+	assert.NoError(t, checkDoneDir(dir, doneDir))
+	assert.ErrorContains(t, checkDoneDir(dir, dir), "-done-dir must differ from -watch-dir")
+	assert.ErrorContains(t, checkDoneDir(dir, filepath.Join(dir, "vmlinux")), "is not a directory")
+	assert.ErrorContains(t, checkDoneDir(dir, filepath.Join(dir, "missing")), "bad -done-dir")
+}